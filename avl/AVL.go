@@ -0,0 +1,330 @@
+package avl
+
+type node[K any, V any] struct {
+	key     K
+	val     V
+	l, r, p *node[K, V]
+	h       int // height of the subtree rooted here, a leaf has height 1
+}
+
+// AVL is a binary search tree that keeps the heights of the two child subtrees
+// of every node within one of each other. this is stricter than the red-black tree,
+// so lookups are a little faster at the cost of more rotations on insert and remove
+type AVL[K any, V any] struct {
+	root    *node[K, V]
+	size    int
+	compare func(K, K) int
+}
+
+func newNode[K any, V any](k K, v V) *node[K, V] {
+	return &node[K, V]{
+		key: k,
+		val: v,
+		h:   1,
+	}
+}
+
+func New[K any, V any](compare func(K, K) int) *AVL[K, V] {
+	return &AVL[K, V]{root: nil, compare: compare}
+}
+
+func (t *AVL[K, V]) Insert(k K, v V) (oldValue V, replaced bool) {
+
+	x := t.root
+	var y *node[K, V] = nil
+	z := newNode(k, v)
+	for x != nil {
+		y = x
+		cmp := t.compare(z.key, x.key)
+		if cmp == 0 {
+			prev := x.val
+			x.val = v
+			return prev, true
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	z.p = y
+	if y == nil {
+		t.root = z
+	} else if t.compare(z.key, y.key) < 0 {
+		y.l = z
+	} else {
+		y.r = z
+	}
+	t.size++
+	t.rebalance(y)
+	return oldValue, false
+}
+
+// rebalance walks from x to the root, fixing heights and rotating
+// any node whose children differ in height by more than one
+func (t *AVL[K, V]) rebalance(x *node[K, V]) {
+	for x != nil {
+		t.update(x)
+		bf := t.height(x.l) - t.height(x.r)
+		if bf > 1 {
+			// left heavy, a left-right shape needs a rotation on the child first
+			if t.height(x.l.l) < t.height(x.l.r) {
+				t.leftRotate(x.l)
+			}
+			x = t.rightRotate(x)
+		} else if bf < -1 {
+			if t.height(x.r.r) < t.height(x.r.l) {
+				t.rightRotate(x.r)
+			}
+			x = t.leftRotate(x)
+		}
+		x = x.p
+	}
+}
+
+func (t *AVL[K, V]) update(x *node[K, V]) {
+	l := t.height(x.l)
+	r := t.height(x.r)
+	if l < r {
+		x.h = r + 1
+	} else {
+		x.h = l + 1
+	}
+}
+
+// leftRotate returns the node that took the place of x
+func (t *AVL[K, V]) leftRotate(x *node[K, V]) *node[K, V] {
+	y := x.r
+	x.r = y.l
+	if y.l != nil {
+		y.l.p = x
+	}
+	y.p = x.p
+	if x.p == nil {
+		t.root = y
+	} else if x == x.p.l {
+		x.p.l = y
+	} else {
+		x.p.r = y
+	}
+	y.l = x
+	x.p = y
+	t.update(x)
+	t.update(y)
+	return y
+}
+
+// rightRotate returns the node that took the place of x
+func (t *AVL[K, V]) rightRotate(x *node[K, V]) *node[K, V] {
+	y := x.l
+	x.l = y.r
+	if y.r != nil {
+		y.r.p = x
+	}
+	y.p = x.p
+	if x.p == nil {
+		t.root = y
+	} else if x == x.p.r {
+		x.p.r = y
+	} else {
+		x.p.l = y
+	}
+	y.r = x
+	x.p = y
+	t.update(x)
+	t.update(y)
+	return y
+}
+
+// Height calculates how many nodes from the top of the tree
+// to the bottom of the tree on the longest path, including the root
+// this means that the number of possible nodes at each height does not
+// obey the theoretical rule of n=2^h (i.e., height 1 (the root) has one node, not two)
+// every node stores its height, so this is O(1)
+func (t *AVL[K, V]) Height() int {
+	return t.height(t.root)
+}
+
+func (t *AVL[K, V]) height(x *node[K, V]) int {
+	if x == nil {
+		return 0
+	} else {
+		return x.h
+	}
+}
+
+func (t *AVL[K, V]) transplant(u *node[K, V], v *node[K, V]) {
+	if u.p == nil {
+		t.root = v
+	} else if u == u.p.l {
+		u.p.l = v
+	} else {
+		u.p.r = v
+	}
+	if v != nil {
+		v.p = u.p
+	}
+}
+
+func (t *AVL[K, V]) Remove(k K) (oldValue V, found bool) {
+	// search to get the node, then remove it
+	x := t.search(t.root, k)
+	if x != nil {
+		t.remove(x)
+		return x.val, true
+	}
+	return oldValue, false
+}
+
+func (t *AVL[K, V]) remove(z *node[K, V]) {
+	// the lowest node whose subtree changed shape, rebalancing starts here
+	var s *node[K, V]
+	if z.l == nil {
+		s = z.p
+		t.transplant(z, z.r)
+	} else if z.r == nil {
+		s = z.p
+		t.transplant(z, z.l)
+	} else {
+		y := t.minimum(z.r)
+		s = y
+		if y != z.r {
+			s = y.p
+			t.transplant(y, y.r)
+			y.r = z.r
+			y.r.p = y
+		}
+		t.transplant(z, y)
+		y.l = z.l
+		y.l.p = y
+	}
+	t.size--
+	t.rebalance(s)
+}
+
+func (t *AVL[K, V]) Search(k K) (val V, found bool) {
+	x := t.search(t.root, k)
+	if x == nil {
+		return val, false
+	} else {
+		return x.val, true
+	}
+}
+
+func (t *AVL[K, V]) search(x *node[K, V], k K) *node[K, V] {
+
+	for x != nil && t.compare(x.key, k) != 0 {
+		if t.compare(k, x.key) < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return x
+}
+
+func (t *AVL[K, V]) Clear() {
+	// dropping the root lets the garbage collector have the nodes,
+	// there are no outside references into the tree
+	t.root = nil
+	t.size = 0
+}
+
+func (t *AVL[K, V]) Size() int {
+	return t.size
+}
+
+func (t *AVL[K, V]) ContainsKey(k K) bool {
+	x := t.search(t.root, k)
+	if x != nil {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (t *AVL[K, V]) ContainsValue(v V, cmp func(V, V) int) bool {
+	if t.root == nil {
+		return false
+	}
+	// minimum then each successor, stopping at the first match
+	for x := t.minimum(t.root); x != nil; x = t.successor(x) {
+		if cmp(x.val, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *AVL[K, V]) minimum(x *node[K, V]) *node[K, V] {
+	for x.l != nil {
+		x = x.l
+	}
+	return x
+}
+
+func (t *AVL[K, V]) maximum(x *node[K, V]) *node[K, V] {
+	for x.r != nil {
+		x = x.r
+	}
+	return x
+}
+
+func (t *AVL[K, V]) Successor(k K) (val V, found bool) {
+	x := t.search(t.root, k)
+	if x == nil {
+		return val, false
+	}
+	x = t.successor(x)
+	if x != nil {
+		return x.val, true
+	} else {
+		return val, false
+	}
+}
+
+func (t *AVL[K, V]) successor(x *node[K, V]) *node[K, V] {
+	if x.r != nil {
+		return t.minimum(x.r)
+	} else {
+		y := x.p
+		for y != nil && x == y.r {
+			x = y
+			y = y.p
+		}
+		return y
+	}
+}
+
+func (t *AVL[K, V]) Predecessor(k K) (val V, found bool) {
+	x := t.search(t.root, k)
+	if x == nil {
+		return val, false
+	}
+	x = t.predecessor(x)
+	if x != nil {
+		return x.val, true
+	} else {
+		return val, false
+	}
+}
+
+func (t *AVL[K, V]) predecessor(x *node[K, V]) *node[K, V] {
+	if x.l != nil {
+		return t.maximum(x.l)
+	} else {
+		y := x.p
+		for y != nil && x == y.l {
+			x = y
+			y = y.p
+		}
+		return y
+	}
+}
+
+func (t *AVL[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
+	if x != nil {
+		t.traverse(x.l, action)
+		action(x)
+		t.traverse(x.r, action)
+	}
+}
@@ -0,0 +1,393 @@
+package avl
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func BenchmarkAVL_Insert(b *testing.B) {
+
+	tree := New[int, int](func(a int, b int) int {
+		return a - b
+	})
+	r := rand.New(rand.NewSource(123))
+	duplicates := 0
+
+	for i := 0; i < b.N; i++ {
+		obj := r.Int()
+		_, found := tree.Insert(obj, obj)
+		if found {
+			duplicates++
+		}
+	}
+
+	//fmt.Printf("<<<%d>>>", tree.Height())
+}
+
+func BenchmarkAVL_InOrderInsert(b *testing.B) {
+
+	tree := New[int, int](func(a int, b int) int {
+		return a - b
+	})
+	duplicates := 0
+
+	for i := 0; i < b.N; i++ {
+		_, found := tree.Insert(i, i)
+		if found {
+			duplicates++
+		}
+	}
+
+	//fmt.Printf("<<<%d>>>", tree.Height())
+}
+
+func BenchmarkAVL_Search(b *testing.B) {
+
+	tree := New[int, int](func(a int, b int) int {
+		return a - b
+	})
+	for i := 0; i < 100000; i++ {
+		tree.Insert(i, i)
+	}
+	r := rand.New(rand.NewSource(123))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Search(r.Intn(100000))
+	}
+}
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+func TestAVL_Insert(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	x := valueSlice(b)
+
+	if !slices.IsSortedFunc(x, strings.Compare) {
+		t.Errorf("inserts were not sorted!")
+	}
+
+	if !slices.Contains(x, "1") {
+		t.Errorf("missing 1")
+	}
+
+	if !slices.Contains(x, "3") {
+		t.Errorf("missing 3")
+	}
+
+	if !slices.Contains(x, "4") {
+		t.Errorf("missing 4")
+	}
+
+	if !slices.Contains(x, "5") {
+		t.Errorf("missing 5")
+	}
+
+	checkBalance(t, b)
+}
+
+func TestAVL_InsertDup(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	got, _ := b.Insert(4, "166")
+	if got != "4" {
+		t.Errorf("inserting a duplicate, should see previous value 4, got %s", got)
+	}
+
+	got, _ = b.Search(4)
+
+	if got != "166" {
+		t.Errorf("duplicate Key K was replaces with 166, got %s", got)
+	}
+
+	if b.Size() != 4 {
+		t.Errorf("duplicate should not change the size, got %d", b.Size())
+	}
+}
+
+func TestAVL_Predecessor(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+	b.Insert(3, 3)
+	b.Insert(2, 2)
+	b.Insert(4, 4)
+	b.Insert(7, 7)
+	b.Insert(13, 13)
+	b.Insert(9, 9)
+	b.Insert(18, 18)
+	b.Insert(17, 17)
+	b.Insert(20, 20)
+
+	_, found := b.Predecessor(2)
+	if found {
+		t.Errorf("there should be no predecessor of min")
+	}
+	got, _ := b.Predecessor(15)
+	if got != 13 {
+		t.Errorf("didn't find max of min side, got %d", got)
+	}
+	got, _ = b.Predecessor(17)
+	if got != 15 {
+		t.Errorf("didn't traverse up properly, got %d", got)
+	}
+	_, found = b.Predecessor(10)
+	if found {
+		t.Errorf("there should be no predecessor of a missing key")
+	}
+}
+
+func TestAVL_Successor(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+	b.Insert(3, 3)
+	b.Insert(2, 2)
+	b.Insert(4, 4)
+	b.Insert(7, 7)
+	b.Insert(13, 13)
+	b.Insert(9, 9)
+	b.Insert(18, 18)
+	b.Insert(17, 17)
+	b.Insert(20, 20)
+
+	got, found := b.Successor(20)
+	if found {
+		t.Errorf("there should be no successor of max, got %d", got)
+	}
+	got, _ = b.Successor(15)
+	if got != 17 {
+		t.Errorf("didn't find min of max side, got %d", got)
+	}
+	got, _ = b.Successor(13)
+	if got != 15 {
+		t.Errorf("didn't traverse up properly, got %d", got)
+	}
+	_, found = b.Successor(10)
+	if found {
+		t.Errorf("there should be no successor of a missing key")
+	}
+}
+
+func TestAVL_Clear(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+	b.Insert(3, 3)
+	b.Insert(2, 2)
+	b.Insert(4, 4)
+
+	b.Clear()
+
+	if b.Size() != 0 {
+		t.Errorf("after clear there should be no nodes! got %d", b.Size())
+	}
+
+	if b.root != nil {
+		t.Errorf("t.root is not nil")
+	}
+}
+
+func TestAVL_Size(t *testing.T) {
+
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	if b.Size() != 4 {
+		t.Errorf("incorrect size, expected 4, got %d", b.Size())
+	}
+
+	b.Remove(3)
+
+	if b.Size() != 3 {
+		t.Errorf("incorrect size, expected 3, got %d", b.Size())
+	}
+
+	b.Remove(3)
+
+	if b.Size() != 3 {
+		t.Errorf("removing a missing key changed the size, got %d", b.Size())
+	}
+}
+
+func TestAVL_Height(t *testing.T) {
+	// in order inserts are the worst case for bst.BST,
+	// here 2^k-1 sorted keys should build a perfect tree
+	b := New[int, int](intcmp)
+
+	for i := 1; i <= 15; i++ {
+		b.Insert(i, i)
+	}
+
+	if b.Height() != 4 {
+		t.Errorf("Height was expected 4, got %d", b.Height())
+	}
+
+	for i := 16; i <= 1023; i++ {
+		b.Insert(i, i)
+	}
+
+	if b.Height() != 10 {
+		t.Errorf("Height was expected 10, got %d", b.Height())
+	}
+	checkBalance(t, b)
+}
+
+func TestAVL_Remove(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+	b.Insert(3, 3)
+	b.Insert(2, 2)
+	b.Insert(4, 4)
+	b.Insert(7, 7)
+	b.Insert(13, 13)
+	b.Insert(9, 9)
+	b.Insert(18, 18)
+	b.Insert(17, 17)
+	b.Insert(20, 20)
+
+	for _, k := range []int{13, 2, 3, 17, 15} {
+		got, found := b.Remove(k)
+		if !found || got != k {
+			t.Errorf("%d not returned by remove", k)
+		}
+
+		x := valueSlice(b)
+		if !slices.IsSortedFunc(x, intcmp) {
+			t.Errorf("tree structure incorrect")
+		}
+		if slices.Contains(x, k) {
+			t.Errorf("%d not removed", k)
+		}
+		checkBalance(t, b)
+	}
+
+	b.Insert(19, 19)
+	b.Insert(25, 25)
+	b.Insert(21, 21)
+	b.Insert(23, 23)
+	b.Insert(22, 22)
+	b.Insert(24, 24)
+
+	got, _ := b.Remove(20)
+	if got != 20 {
+		t.Errorf("20 not returned by remove")
+	}
+	checkBalance(t, b)
+
+	if b.Size() != 11 {
+		t.Errorf("Size incorrect! expected 11 but was %d", b.Size())
+	}
+
+	_, found := b.Remove(2500)
+	if found {
+		t.Errorf("error, should return nil for keys not in the tree")
+	}
+}
+
+func TestAVL_RandomBalance(t *testing.T) {
+	b := New[int, int](intcmp)
+	r := rand.New(rand.NewSource(321))
+
+	inserted := map[int]bool{}
+	for i := 0; i < 2000; i++ {
+		k := r.Intn(500)
+		b.Insert(k, k)
+		inserted[k] = true
+		if i%2 == 0 {
+			d := r.Intn(500)
+			b.Remove(d)
+			delete(inserted, d)
+		}
+	}
+
+	if b.Size() != len(inserted) {
+		t.Errorf("size %d, want %d", b.Size(), len(inserted))
+	}
+	if !slices.IsSorted(keySlice(b)) {
+		t.Errorf("keys are not sorted")
+	}
+	checkBalance(t, b)
+}
+
+func TestAVL_ContainsValue(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+
+	if !b.ContainsValue("3", strings.Compare) {
+		t.Errorf("should contain value 3")
+	}
+	if b.ContainsValue("4", strings.Compare) {
+		t.Errorf("should not contain value 4")
+	}
+	if !b.ContainsKey(5) || b.ContainsKey(4) {
+		t.Errorf("ContainsKey incorrect")
+	}
+}
+
+// checkBalance recomputes every height and checks the AVL property and parent pointers
+func checkBalance[K any, V any](t *testing.T, b *AVL[K, V]) {
+	t.Helper()
+	var check func(x *node[K, V]) int
+	check = func(x *node[K, V]) int {
+		if x == nil {
+			return 0
+		}
+		if x.l != nil && x.l.p != x || x.r != nil && x.r.p != x {
+			t.Errorf("broken parent pointer at %v", x.key)
+		}
+		l := check(x.l)
+		r := check(x.r)
+		if l-r > 1 || r-l > 1 {
+			t.Errorf("node %v is unbalanced, left %d right %d", x.key, l, r)
+		}
+		h := max(l, r) + 1
+		if x.h != h {
+			t.Errorf("node %v has height %d, want %d", x.key, x.h, h)
+		}
+		return h
+	}
+	check(b.root)
+}
+
+func valueSlice[K any, V any](b *AVL[K, V]) []V {
+	var x []V
+	b.traverse(b.root, func(n *node[K, V]) {
+		x = append(x, n.val)
+	})
+	return x
+}
+
+func keySlice[K any, V any](b *AVL[K, V]) []K {
+	var x []K
+	b.traverse(b.root, func(n *node[K, V]) {
+		x = append(x, n.key)
+	})
+	return x
+}
@@ -1,11 +1,18 @@
 package bst
 
-import "github.com/a-tk/go-datastructures/stack"
+import (
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/a-tk/go-datastructures/stack"
+)
 
 type node[K any, V any] struct {
 	key     K
 	val     V
 	l, r, p *node[K, V]
+	size    int // number of nodes in the subtree rooted here, including itself
 }
 
 type BST[K any, V any] struct {
@@ -15,8 +22,9 @@ type BST[K any, V any] struct {
 
 func newNode[K any, V any](k K, v V) *node[K, V] {
 	return &node[K, V]{
-		key: k,
-		val: v,
+		key:  k,
+		val:  v,
+		size: 1,
 	}
 }
 
@@ -24,6 +32,38 @@ func New[K any, V any](compare func(K, K) int) *BST[K, V] {
 	return &BST[K, V]{root: nil, compare: compare}
 }
 
+// ErrUnsorted is returned by FromSorted when the keys are not strictly increasing
+var ErrUnsorted = errors.New("bst: input is not sorted")
+
+// FromSorted builds a perfectly balanced tree in O(n) from seq, which must yield
+// strictly increasing keys. repeated Insert of sorted keys would build a chain instead
+func FromSorted[K any, V any](compare func(K, K) int, seq iter.Seq2[K, V]) (*BST[K, V], error) {
+	var nodes []*node[K, V]
+	for k, v := range seq {
+		if n := len(nodes); n > 0 && compare(nodes[n-1].key, k) >= 0 {
+			return nil, fmt.Errorf("%w: key %v at position %d does not follow %v", ErrUnsorted, k, n, nodes[n-1].key)
+		}
+		nodes = append(nodes, newNode(k, v))
+	}
+	t := New[K, V](compare)
+	t.root = t.build(nodes, nil)
+	return t, nil
+}
+
+// build links the middle node of nodes as the root of the subtree, then each half under it
+func (t *BST[K, V]) build(nodes []*node[K, V], p *node[K, V]) *node[K, V] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	x := nodes[mid]
+	x.p = p
+	x.l = t.build(nodes[:mid], x)
+	x.r = t.build(nodes[mid+1:], x)
+	x.size = len(nodes)
+	return x
+}
+
 func (t *BST[K, V]) Insert(k K, v V) (oldValue V, replaced bool) {
 
 	x := t.root
@@ -50,6 +90,9 @@ func (t *BST[K, V]) Insert(k K, v V) (oldValue V, replaced bool) {
 	} else {
 		y.r = z
 	}
+	for ; y != nil; y = y.p {
+		y.size++
+	}
 	return oldValue, false
 }
 
@@ -99,13 +142,19 @@ func (t *BST[K, V]) Remove(k K) (oldValue V, found bool) {
 }
 
 func (t *BST[K, V]) remove(z *node[K, V]) {
+	// the lowest node whose subtree lost a node, sizes are fixed from here to the root
+	var s *node[K, V]
 	if z.l == nil {
+		s = z.p
 		t.transplant(z, z.r)
 	} else if z.r == nil {
+		s = z.p
 		t.transplant(z, z.l)
 	} else {
 		y := t.minimum(z.r)
+		s = y
 		if y != z.r {
+			s = y.p
 			t.transplant(y, y.r)
 			y.r = z.r
 			y.r.p = y
@@ -114,6 +163,9 @@ func (t *BST[K, V]) remove(z *node[K, V]) {
 		y.l = z.l
 		y.l.p = y
 	}
+	for ; s != nil; s = s.p {
+		s.size = 1 + t.size(s.l) + t.size(s.r)
+	}
 }
 
 func (t *BST[K, V]) Search(k K) (val V, found bool) {
@@ -145,6 +197,7 @@ func (t *BST[K, V]) Clear() {
 	})
 }
 
+// Size is O(1), every node keeps the size of its subtree
 func (t *BST[K, V]) Size() int {
 	return t.size(t.root)
 }
@@ -153,8 +206,51 @@ func (t *BST[K, V]) size(x *node[K, V]) int {
 	if x == nil {
 		return 0
 	} else {
-		return 1 + t.size(x.l) + t.size(x.r)
+		return x.size
+	}
+}
+
+// Select finds the i-th smallest key, counting from 0, in O(h)
+func (t *BST[K, V]) Select(i int) (k K, v V, found bool) {
+	x := t.root
+	for x != nil {
+		l := t.size(x.l)
+		if i == l {
+			return x.key, x.val, true
+		} else if i < l {
+			x = x.l
+		} else {
+			i = i - l - 1
+			x = x.r
+		}
+	}
+	return k, v, false
+}
+
+// Rank counts the keys strictly less than k, in O(h). k does not need to be in the tree
+func (t *BST[K, V]) Rank(k K) int {
+	r := 0
+	x := t.root
+	for x != nil {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return r + t.size(x.l)
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			r = r + t.size(x.l) + 1
+			x = x.r
+		}
+	}
+	return r
+}
+
+// CountRange counts the keys k where lo <= k < hi, matching Range
+func (t *BST[K, V]) CountRange(lo, hi K) int {
+	if t.compare(lo, hi) >= 0 {
+		return 0
 	}
+	return t.Rank(hi) - t.Rank(lo)
 }
 
 func (t *BST[K, V]) ContainsKey(k K) bool {
@@ -167,16 +263,13 @@ func (t *BST[K, V]) ContainsKey(k K) bool {
 }
 
 func (t *BST[K, V]) ContainsValue(v V, cmp func(V, V) int) bool {
-	found := false
-
-	// can't stop the traversal :(
-	t.traverse(t.root, func(n *node[K, V]) {
-		if cmp(n.val, v) == 0 {
-			found = true
-		}
-	})
 	// java TreeMap finds the minimum then each successor, clever!
-	return found
+	for _, val := range t.All() {
+		if cmp(val, v) == 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *BST[K, V]) minimum(x *node[K, V]) *node[K, V] {
@@ -195,6 +288,9 @@ func (t *BST[K, V]) maximum(x *node[K, V]) *node[K, V] {
 
 func (t *BST[K, V]) Successor(k K) (val V, found bool) {
 	x := t.search(t.root, k)
+	if x == nil {
+		return val, false
+	}
 	x = t.successor(x)
 	if x != nil {
 		return x.val, true
@@ -218,6 +314,9 @@ func (t *BST[K, V]) successor(x *node[K, V]) *node[K, V] {
 
 func (t *BST[K, V]) Predecessor(k K) (val V, found bool) {
 	x := t.search(t.root, k)
+	if x == nil {
+		return val, false
+	}
 	x = t.predecessor(x)
 	if x != nil {
 		return x.val, true
@@ -239,6 +338,118 @@ func (t *BST[K, V]) predecessor(x *node[K, V]) *node[K, V] {
 	}
 }
 
+// Min finds the smallest key in the tree
+func (t *BST[K, V]) Min() (k K, v V, found bool) {
+	if t.root == nil {
+		return k, v, false
+	}
+	x := t.minimum(t.root)
+	return x.key, x.val, true
+}
+
+// Max finds the largest key in the tree
+func (t *BST[K, V]) Max() (k K, v V, found bool) {
+	if t.root == nil {
+		return k, v, false
+	}
+	x := t.maximum(t.root)
+	return x.key, x.val, true
+}
+
+// Floor finds the largest key <= k. Unlike Predecessor, k does not need to be in the tree
+func (t *BST[K, V]) Floor(k K) (key K, val V, found bool) {
+	return t.entry(t.floor(k))
+}
+
+// Ceiling finds the smallest key >= k
+func (t *BST[K, V]) Ceiling(k K) (key K, val V, found bool) {
+	return t.entry(t.ceiling(k))
+}
+
+// Lower finds the largest key strictly < k
+func (t *BST[K, V]) Lower(k K) (key K, val V, found bool) {
+	return t.entry(t.lower(k))
+}
+
+// Higher finds the smallest key strictly > k
+func (t *BST[K, V]) Higher(k K) (key K, val V, found bool) {
+	return t.entry(t.higher(k))
+}
+
+func (t *BST[K, V]) entry(x *node[K, V]) (k K, v V, found bool) {
+	if x == nil {
+		return k, v, false
+	}
+	return x.key, x.val, true
+}
+
+// floor finds the node with the largest key <= k, or nil.
+// going right means x is a candidate, going left means it is too large
+func (t *BST[K, V]) floor(k K) *node[K, V] {
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			y = x
+			x = x.r
+		}
+	}
+	return y
+}
+
+// ceiling finds the node with the smallest key >= k, or nil
+func (t *BST[K, V]) ceiling(k K) *node[K, V] {
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x
+		} else if cmp < 0 {
+			y = x
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return y
+}
+
+// lower finds the node with the largest key < k, or nil
+func (t *BST[K, V]) lower(k K) *node[K, V] {
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		if t.compare(k, x.key) <= 0 {
+			x = x.l
+		} else {
+			y = x
+			x = x.r
+		}
+	}
+	return y
+}
+
+// higher finds the node with the smallest key > k, or nil
+func (t *BST[K, V]) higher(k K) *node[K, V] {
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		if t.compare(k, x.key) < 0 {
+			y = x
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return y
+}
+
 func (t *BST[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
 	if x != nil {
 		t.traverse(x.l, action)
@@ -269,3 +480,95 @@ func (t *BST[K, V]) traverseIter(action func(*node[K, V])) {
 		current = current.r
 	}
 }
+
+// All returns an iterator over every key and value in ascending key order.
+// the walk starts at the minimum and follows successors, so breaking out
+// of the loop stops the traversal early
+func (t *BST[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		for x := t.minimum(t.root); x != nil; x = t.successor(x) {
+			if !yield(x.key, x.val) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over every key and value in descending key order
+func (t *BST[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		for x := t.maximum(t.root); x != nil; x = t.predecessor(x) {
+			if !yield(x.key, x.val) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the keys k where lo <= k < hi, in ascending order.
+// finding the first key is O(h), then each step is amortized O(1), giving O(h + m)
+func (t *BST[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for x := t.ceiling(lo); x != nil && t.compare(x.key, hi) < 0; x = t.successor(x) {
+			if !yield(x.key, x.val) {
+				return
+			}
+		}
+	}
+}
+
+// ValidationError describes a broken invariant and where it was found.
+// Path is the route from the root, e.g. "root/L/R" is the right child of the root's left child
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("bst: %s at %s", e.Reason, e.Path)
+}
+
+// Validate checks that keys are ordered, parent pointers agree with child pointers
+// and every subtree size is correct. it returns the first problem found, or nil
+func (t *BST[K, V]) Validate() error {
+	if t.root != nil && t.root.p != nil {
+		return &ValidationError{Path: "root", Reason: "root has a parent"}
+	}
+	_, err := t.validate(t.root, nil, nil, "root")
+	return err
+}
+
+// validate checks the subtree at x, where every key must be strictly between lo and hi
+// when they are not nil, and returns the number of nodes in it
+func (t *BST[K, V]) validate(x *node[K, V], lo, hi *K, path string) (int, error) {
+	if x == nil {
+		return 0, nil
+	}
+	if lo != nil && t.compare(x.key, *lo) <= 0 || hi != nil && t.compare(x.key, *hi) >= 0 {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("key %v is out of order", x.key)}
+	}
+	if x.l != nil && x.l.p != x {
+		return 0, &ValidationError{Path: path + "/L", Reason: "parent pointer does not point back"}
+	}
+	if x.r != nil && x.r.p != x {
+		return 0, &ValidationError{Path: path + "/R", Reason: "parent pointer does not point back"}
+	}
+	l, err := t.validate(x.l, lo, &x.key, path+"/L")
+	if err != nil {
+		return 0, err
+	}
+	r, err := t.validate(x.r, &x.key, hi, path+"/R")
+	if err != nil {
+		return 0, err
+	}
+	if x.size != l+r+1 {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("size is %d but the subtree has %d nodes", x.size, l+r+1)}
+	}
+	return x.size, nil
+}
@@ -1,6 +1,8 @@
 package bst
 
 import (
+	"errors"
+	"math/bits"
 	"math/rand"
 	"slices"
 	"strings"
@@ -437,3 +439,389 @@ func keySlice[K any, V any](b *BST[K, V]) []K {
 	})
 	return x
 }
+
+func TestBST_All(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	var keys []int
+	for k, v := range b.All() {
+		if v != k*10 {
+			t.Errorf("value for %d should be %d, got %d", k, k*10, v)
+		}
+		keys = append(keys, k)
+	}
+
+	if !slices.Equal(keys, keySlice(b)) {
+		t.Errorf("All did not match in order traversal, got %v", keys)
+	}
+
+	// break early
+	keys = nil
+	for k := range b.All() {
+		if k > 6 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{2, 3, 4, 6}) {
+		t.Errorf("early break should stop at 6, got %v", keys)
+	}
+
+	empty := New[int, int](intcmp)
+	for k := range empty.All() {
+		t.Errorf("empty tree should not yield, got %d", k)
+	}
+}
+
+func TestBST_Backward(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	var keys []int
+	for k := range b.Backward() {
+		keys = append(keys, k)
+	}
+
+	want := keySlice(b)
+	slices.Reverse(want)
+	if !slices.Equal(keys, want) {
+		t.Errorf("Backward should be reverse order, got %v", keys)
+	}
+
+	keys = nil
+	for k := range b.Backward() {
+		if k < 17 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{20, 18, 17}) {
+		t.Errorf("early break should stop at 17, got %v", keys)
+	}
+}
+
+func TestBST_Range(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	var keys []int
+	for k := range b.Range(4, 15) {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{4, 6, 7, 9, 13}) {
+		t.Errorf("Range(4, 15) got %v", keys)
+	}
+
+	// bounds not in the tree
+	keys = nil
+	for k := range b.Range(5, 19) {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{6, 7, 9, 13, 15, 17, 18}) {
+		t.Errorf("Range(5, 19) got %v", keys)
+	}
+
+	keys = nil
+	for k := range b.Range(21, 30) {
+		keys = append(keys, k)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Range past the maximum should be empty, got %v", keys)
+	}
+
+	keys = nil
+	for k := range b.Range(0, 100) {
+		if k == 7 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{2, 3, 4, 6}) {
+		t.Errorf("early break should stop at 7, got %v", keys)
+	}
+}
+
+func TestBST_ContainsValue(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+
+	if !b.ContainsValue("4", strings.Compare) {
+		t.Errorf("should contain value 4")
+	}
+	if b.ContainsValue("6", strings.Compare) {
+		t.Errorf("should not contain value 6")
+	}
+}
+
+func TestBST_SizeAugmented(t *testing.T) {
+	b := New[int, int](intcmp)
+	r := rand.New(rand.NewSource(321))
+
+	inserted := map[int]bool{}
+	for i := 0; i < 500; i++ {
+		k := r.Intn(200)
+		b.Insert(k, k)
+		inserted[k] = true
+		if i%3 == 0 {
+			d := r.Intn(200)
+			b.Remove(d)
+			delete(inserted, d)
+		}
+		if b.Size() != len(inserted) {
+			t.Fatalf("size %d, want %d", b.Size(), len(inserted))
+		}
+	}
+
+	// every node should agree with a recount of its subtree
+	var count func(x *node[int, int]) int
+	count = func(x *node[int, int]) int {
+		if x == nil {
+			return 0
+		}
+		n := 1 + count(x.l) + count(x.r)
+		if x.size != n {
+			t.Errorf("node %d has size %d, want %d", x.key, x.size, n)
+		}
+		return n
+	}
+	count(b.root)
+}
+
+func TestBST_Select(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	keys := keySlice(b)
+	for i, want := range keys {
+		k, v, found := b.Select(i)
+		if !found || k != want || v != want*10 {
+			t.Errorf("Select(%d) got %d %d %t, want %d", i, k, v, found, want)
+		}
+	}
+
+	if _, _, found := b.Select(len(keys)); found {
+		t.Errorf("Select past the end should not be found")
+	}
+	if _, _, found := b.Select(-1); found {
+		t.Errorf("Select(-1) should not be found")
+	}
+}
+
+func TestBST_Rank(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	tests := []struct {
+		k, want int
+	}{
+		{1, 0}, {2, 0}, {3, 1}, {5, 3}, {15, 7}, {16, 8}, {20, 10}, {21, 11},
+	}
+	for _, tt := range tests {
+		if got := b.Rank(tt.k); got != tt.want {
+			t.Errorf("Rank(%d) got %d, want %d", tt.k, got, tt.want)
+		}
+	}
+
+	if got := b.CountRange(4, 15); got != 5 {
+		t.Errorf("CountRange(4, 15) got %d, want 5", got)
+	}
+	if got := b.CountRange(5, 19); got != 7 {
+		t.Errorf("CountRange(5, 19) got %d, want 7", got)
+	}
+	if got := b.CountRange(19, 5); got != 0 {
+		t.Errorf("CountRange(19, 5) got %d, want 0", got)
+	}
+}
+
+func TestBST_SuccessorMissingKey(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+
+	if _, found := b.Successor(10); found {
+		t.Errorf("successor of a missing key should not be found")
+	}
+	if _, found := b.Predecessor(10); found {
+		t.Errorf("predecessor of a missing key should not be found")
+	}
+}
+
+func TestBST_Navigable(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	type query func(int) (int, int, bool)
+	tests := []struct {
+		name  string
+		q     query
+		k     int
+		want  int
+		found bool
+	}{
+		{"Floor", b.Floor, 13, 13, true},
+		{"Floor", b.Floor, 14, 13, true},
+		{"Floor", b.Floor, 1, 0, false},
+		{"Floor", b.Floor, 25, 20, true},
+		{"Ceiling", b.Ceiling, 13, 13, true},
+		{"Ceiling", b.Ceiling, 14, 15, true},
+		{"Ceiling", b.Ceiling, 21, 0, false},
+		{"Ceiling", b.Ceiling, 0, 2, true},
+		{"Lower", b.Lower, 13, 9, true},
+		{"Lower", b.Lower, 14, 13, true},
+		{"Lower", b.Lower, 2, 0, false},
+		{"Higher", b.Higher, 13, 15, true},
+		{"Higher", b.Higher, 16, 17, true},
+		{"Higher", b.Higher, 20, 0, false},
+	}
+	for _, tt := range tests {
+		k, v, found := tt.q(tt.k)
+		if found != tt.found {
+			t.Errorf("%s(%d) found %t, want %t", tt.name, tt.k, found, tt.found)
+			continue
+		}
+		if found && (k != tt.want || v != tt.want*10) {
+			t.Errorf("%s(%d) got %d %d, want %d", tt.name, tt.k, k, v, tt.want)
+		}
+	}
+
+	if k, _, _ := b.Min(); k != 2 {
+		t.Errorf("Min got %d, want 2", k)
+	}
+	if k, _, _ := b.Max(); k != 20 {
+		t.Errorf("Max got %d, want 20", k)
+	}
+
+	empty := New[int, int](intcmp)
+	if _, _, found := empty.Min(); found {
+		t.Errorf("empty tree has no minimum")
+	}
+	if _, _, found := empty.Max(); found {
+		t.Errorf("empty tree has no maximum")
+	}
+	if _, _, found := empty.Floor(3); found {
+		t.Errorf("empty tree has no floor")
+	}
+}
+
+func TestBST_Validate(t *testing.T) {
+	b := New[int, int](intcmp)
+	r := rand.New(rand.NewSource(321))
+
+	if err := b.Validate(); err != nil {
+		t.Errorf("empty tree should be valid, got %v", err)
+	}
+
+	for i := 0; i < 500; i++ {
+		b.Insert(r.Intn(200), i)
+		if i%3 == 0 {
+			b.Remove(r.Intn(200))
+		}
+		if err := b.Validate(); err != nil {
+			t.Fatalf("valid tree reported %v", err)
+		}
+	}
+
+	b = New[int, int](intcmp)
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	// swap two keys to break the ordering
+	b.root.l.key, b.root.r.key = b.root.r.key, b.root.l.key
+	err := b.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if verr.Path != "root/L" {
+		t.Errorf("expected the error at root/L, got %s", verr.Path)
+	}
+	b.root.l.key, b.root.r.key = b.root.r.key, b.root.l.key
+
+	b.root.r.p = b.root.l
+	if err := b.Validate(); err == nil || !strings.Contains(err.Error(), "root/R") {
+		t.Errorf("expected a parent pointer error at root/R, got %v", err)
+	}
+	b.root.r.p = b.root
+
+	b.root.size++
+	if err := b.Validate(); err == nil {
+		t.Errorf("expected a size error")
+	}
+}
+
+func TestBST_FromSorted(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 7, 8, 100, 1023} {
+		b, err := FromSorted(intcmp, func(yield func(int, int) bool) {
+			for i := 0; i < n; i++ {
+				if !yield(i*2, i) {
+					return
+				}
+			}
+		})
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("n=%d: %v", n, err)
+		}
+		if b.Size() != n {
+			t.Errorf("n=%d: size is %d", n, b.Size())
+		}
+		// perfectly balanced, so as short as a tree of n nodes can be
+		if h, want := b.Height(), bits.Len(uint(n)); h != want {
+			t.Errorf("n=%d: height is %d, expected %d", n, h, want)
+		}
+		i := 0
+		for k, v := range b.All() {
+			if k != i*2 || v != i {
+				t.Errorf("n=%d: entry %d is (%d, %d)", n, i, k, v)
+			}
+			i++
+		}
+		// the tree still works as usual afterwards
+		b.Insert(-1, -1)
+		b.Remove(0)
+		if err := b.Validate(); err != nil {
+			t.Errorf("n=%d: after modifying, %v", n, err)
+		}
+	}
+}
+
+func TestBST_FromSortedUnsorted(t *testing.T) {
+	for _, keys := range [][]int{{1, 3, 2}, {1, 1}} {
+		_, err := FromSorted(intcmp, func(yield func(int, int) bool) {
+			for _, k := range keys {
+				if !yield(k, k) {
+					return
+				}
+			}
+		})
+		if !errors.Is(err, ErrUnsorted) {
+			t.Errorf("%v: expected ErrUnsorted, got %v", keys, err)
+		}
+	}
+}
@@ -5,6 +5,9 @@ package btree
 // make the node impl the interface? then be in charge of where to write it?
 // Ask for the length of the byte slice, to figure out where/how to write to the disk maybe?
 
+// the pager package now has the file of fixed-size pages and a node layout with
+// child page IDs, nodes still need to move onto it
+
 // here, an array of pointers offers more advantages than direct object storage in an array
 // for sparse trees, 50% space in the arrays may be wasted
 // this consideration is only for trees held entirely in memory
@@ -0,0 +1,177 @@
+package pager
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// Codec turns keys or values into bytes and back. it has the same methods as
+// btree_mem.Codec, so the codecs from that package can be used here as well.
+// Decode is handed bytes inside the page, and must copy anything it keeps
+type Codec[T any] interface {
+	Append(dst []byte, v T) []byte
+	Decode(src []byte) (T, error)
+}
+
+// Node is a btree node as stored in one page. an internal node has len(Keys)+1
+// Children, a leaf has none
+type Node[K any, V any] struct {
+	Leaf     bool
+	Keys     []K
+	Vals     []V
+	Children []PageID
+}
+
+// a node page, all fixed size integers little endian
+//
+//	0   kind, 1 for a leaf, 2 for an internal node
+//	1   key count n, u16
+//	3   n+1 child page IDs, u64 each, internal nodes only
+//	    n keys and values, each a uvarint length followed by its codec's bytes
+//	    zeros up to the checksum
+//	-4  CRC-32C of everything before it, u32
+const (
+	kindLeaf     = 1
+	kindInternal = 2
+	nodeHeader   = 3
+	nodeTrailer  = 4
+)
+
+var (
+	// ErrNodeTooLarge is returned by Encode when a node does not fit in a page,
+	// the btree should split it
+	ErrNodeTooLarge = errors.New("pager: node does not fit in a page")
+	// ErrBadNode is returned by Decode for a page that does not hold a valid node
+	ErrBadNode = errors.New("pager: bad node page")
+)
+
+// Layout encodes nodes into pages and back with the given codecs
+type Layout[K any, V any] struct {
+	Keys Codec[K]
+	Vals Codec[V]
+}
+
+// Size is the number of bytes n needs in a page, including the checksum, so the btree
+// can tell if a node will fit before it is written
+func (l Layout[K, V]) Size(n *Node[K, V]) int {
+	size := nodeHeader + nodeTrailer
+	if !n.Leaf {
+		size += 8 * len(n.Children)
+	}
+	var item []byte
+	for i := range n.Keys {
+		item = l.Keys.Append(item[:0], n.Keys[i])
+		size += uvarintLen(len(item)) + len(item)
+		item = l.Vals.Append(item[:0], n.Vals[i])
+		size += uvarintLen(len(item)) + len(item)
+	}
+	return size
+}
+
+func uvarintLen(x int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(x))
+}
+
+// Encode writes n over the whole of page. when it fails page is left as it was, so a
+// node that is too large can be split and the halves written instead
+func (l Layout[K, V]) Encode(page []byte, n *Node[K, V]) error {
+	if len(n.Vals) != len(n.Keys) || (!n.Leaf && len(n.Children) != len(n.Keys)+1) || (n.Leaf && len(n.Children) != 0) {
+		return fmt.Errorf("pager: node has %d keys, %d values and %d children", len(n.Keys), len(n.Vals), len(n.Children))
+	}
+	if len(n.Keys) > 0xffff {
+		return fmt.Errorf("%w: %d keys", ErrNodeTooLarge, len(n.Keys))
+	}
+	end := len(page) - nodeTrailer
+	if end < nodeHeader {
+		return fmt.Errorf("%w: page is too small", ErrNodeTooLarge)
+	}
+	// built on the side and copied in once it is known to fit
+	body := make([]byte, nodeHeader, end)
+	if n.Leaf {
+		body[0] = kindLeaf
+	} else {
+		body[0] = kindInternal
+	}
+	binary.LittleEndian.PutUint16(body[1:], uint16(len(n.Keys)))
+	for _, c := range n.Children {
+		body = binary.LittleEndian.AppendUint64(body, uint64(c))
+	}
+	var item []byte
+	for i := range n.Keys {
+		item = l.Keys.Append(item[:0], n.Keys[i])
+		body = binary.AppendUvarint(body, uint64(len(item)))
+		body = append(body, item...)
+		item = l.Vals.Append(item[:0], n.Vals[i])
+		body = binary.AppendUvarint(body, uint64(len(item)))
+		body = append(body, item...)
+	}
+	if len(body) > end {
+		return fmt.Errorf("%w: needs %d bytes, pages are %d", ErrNodeTooLarge, len(body)+nodeTrailer, len(page))
+	}
+	copy(page, body)
+	clear(page[len(body):end])
+	binary.LittleEndian.PutUint32(page[end:], crc32.Checksum(page[:end], castagnoli))
+	return nil
+}
+
+// Decode reads the node stored in page
+func (l Layout[K, V]) Decode(page []byte) (*Node[K, V], error) {
+	end := len(page) - nodeTrailer
+	if end < nodeHeader {
+		return nil, fmt.Errorf("%w: page is too small", ErrBadNode)
+	}
+	if crc32.Checksum(page[:end], castagnoli) != binary.LittleEndian.Uint32(page[end:]) {
+		return nil, fmt.Errorf("%w: checksum does not match", ErrBadNode)
+	}
+	n := &Node[K, V]{}
+	switch page[0] {
+	case kindLeaf:
+		n.Leaf = true
+	case kindInternal:
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %d", ErrBadNode, page[0])
+	}
+	count := int(binary.LittleEndian.Uint16(page[1:]))
+	body := page[nodeHeader:end]
+	if !n.Leaf {
+		if len(body) < 8*(count+1) {
+			return nil, fmt.Errorf("%w: %d children do not fit", ErrBadNode, count+1)
+		}
+		n.Children = make([]PageID, count+1)
+		for i := range n.Children {
+			n.Children[i] = PageID(binary.LittleEndian.Uint64(body))
+			body = body[8:]
+		}
+	}
+	n.Keys = make([]K, count)
+	n.Vals = make([]V, count)
+	var err error
+	for i := 0; i < count; i++ {
+		var item []byte
+		if item, body, err = next(body); err != nil {
+			return nil, err
+		}
+		if n.Keys[i], err = l.Keys.Decode(item); err != nil {
+			return nil, fmt.Errorf("%w: key %d: %w", ErrBadNode, i, err)
+		}
+		if item, body, err = next(body); err != nil {
+			return nil, err
+		}
+		if n.Vals[i], err = l.Vals.Decode(item); err != nil {
+			return nil, fmt.Errorf("%w: value %d: %w", ErrBadNode, i, err)
+		}
+	}
+	return n, nil
+}
+
+// next splits one length prefixed item off the front of body
+func next(body []byte) (item, rest []byte, err error) {
+	l, n := binary.Uvarint(body)
+	if n <= 0 || l > uint64(len(body)-n) {
+		return nil, nil, fmt.Errorf("%w: item runs past the end of the page", ErrBadNode)
+	}
+	return body[n : n+int(l)], body[n+int(l):], nil
+}
@@ -0,0 +1,276 @@
+// Package pager splits a file into fixed-size pages for the disk btree. page 0 is a
+// header describing the file, every other page is handed out by Allocate and read
+// and written whole by its PageID
+package pager
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// PageID is the index of a page in the file. 0 is the header, so it also means no page
+type PageID uint64
+
+const (
+	DefaultPageSize = 4096
+	// MinPageSize leaves room for the header and a node with a few keys
+	MinPageSize = 128
+	MaxPageSize = 1 << 24
+)
+
+// the header page, all integers little endian. the rest of page 0 is unused
+//
+//	0   magic "BTPG"
+//	4   version u16, 2 bytes reserved
+//	8   page size u32, 4 bytes reserved
+//	16  page count u64, including the header
+//	24  first free page u64
+//	32  root page u64
+//	40  CRC-32C of bytes 0 to 40, u32
+const (
+	magic      = "BTPG"
+	version    = 1
+	headerSize = 44
+)
+
+var (
+	// ErrBadHeader is returned by Open when the file is not a page file it can read
+	ErrBadHeader = errors.New("pager: bad header")
+	// ErrPageSize is returned when a page size is out of range, does not match the file,
+	// or a buffer is not exactly one page
+	ErrPageSize = errors.New("pager: wrong page size")
+	// ErrPageID is returned for the header page or a page past the end of the file
+	ErrPageID = errors.New("pager: page out of range")
+	// ErrFreed is returned by Free for the page at the head of the free list
+	ErrFreed = errors.New("pager: page is already free")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Pager reads and writes the pages of one file. freed pages are kept on a list, linked
+// through their first 8 bytes, and reused by Allocate before the file grows.
+// changes to the header, the page count, free list and root, are written by Sync and
+// Close, so pages allocated after the last Sync are lost if the process dies.
+// a Pager is not safe for concurrent use
+type Pager struct {
+	f         *os.File
+	pageSize  int
+	pageCount uint64
+	free      PageID
+	root      PageID
+	dirty     bool
+}
+
+// Open opens the page file at path, creating it with the given page size if it does
+// not exist. for an existing file pageSize must match the file, or be 0 to accept it
+func Open(path string, pageSize int) (*Pager, error) {
+	if pageSize != 0 && (pageSize < MinPageSize || pageSize > MaxPageSize) {
+		return nil, fmt.Errorf("%w: %d is outside [%d, %d]", ErrPageSize, pageSize, MinPageSize, MaxPageSize)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	p := &Pager{f: f}
+	if info.Size() == 0 {
+		if pageSize == 0 {
+			pageSize = DefaultPageSize
+		}
+		p.pageSize = pageSize
+		p.pageCount = 1
+		p.dirty = true
+		err = p.Sync()
+	} else {
+		err = p.readHeader(pageSize)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Pager) readHeader(pageSize int) error {
+	h := make([]byte, headerSize)
+	if _, err := p.f.ReadAt(h, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: file is too short", ErrBadHeader)
+		}
+		return err
+	}
+	if string(h[0:4]) != magic {
+		return fmt.Errorf("%w: not a page file", ErrBadHeader)
+	}
+	if crc32.Checksum(h[:40], castagnoli) != binary.LittleEndian.Uint32(h[40:]) {
+		return fmt.Errorf("%w: checksum does not match", ErrBadHeader)
+	}
+	if v := binary.LittleEndian.Uint16(h[4:]); v != version {
+		return fmt.Errorf("%w: version %d is not supported", ErrBadHeader, v)
+	}
+	size := int(binary.LittleEndian.Uint32(h[8:]))
+	if size < MinPageSize || size > MaxPageSize {
+		return fmt.Errorf("%w: page size %d is out of range", ErrBadHeader, size)
+	}
+	if pageSize != 0 && pageSize != size {
+		return fmt.Errorf("%w: file has %d byte pages, not %d", ErrPageSize, size, pageSize)
+	}
+	p.pageSize = size
+	p.pageCount = binary.LittleEndian.Uint64(h[16:])
+	p.free = PageID(binary.LittleEndian.Uint64(h[24:]))
+	p.root = PageID(binary.LittleEndian.Uint64(h[32:]))
+	if p.pageCount == 0 || uint64(p.free) >= p.pageCount || uint64(p.root) >= p.pageCount {
+		return fmt.Errorf("%w: page count %d, free %d and root %d do not agree", ErrBadHeader, p.pageCount, p.free, p.root)
+	}
+	return nil
+}
+
+func (p *Pager) writeHeader() error {
+	page := make([]byte, p.pageSize)
+	copy(page, magic)
+	binary.LittleEndian.PutUint16(page[4:], version)
+	binary.LittleEndian.PutUint32(page[8:], uint32(p.pageSize))
+	binary.LittleEndian.PutUint64(page[16:], p.pageCount)
+	binary.LittleEndian.PutUint64(page[24:], uint64(p.free))
+	binary.LittleEndian.PutUint64(page[32:], uint64(p.root))
+	binary.LittleEndian.PutUint32(page[40:], crc32.Checksum(page[:40], castagnoli))
+	_, err := p.f.WriteAt(page, 0)
+	return err
+}
+
+func (p *Pager) PageSize() int {
+	return p.pageSize
+}
+
+// PageCount includes the header page
+func (p *Pager) PageCount() uint64 {
+	return p.pageCount
+}
+
+// Root is the page recorded by SetRoot, or 0 in a new file
+func (p *Pager) Root() PageID {
+	return p.root
+}
+
+// SetRoot records the btree's root page in the header, so it can be found after reopening.
+// 0 clears it
+func (p *Pager) SetRoot(id PageID) error {
+	if id != 0 {
+		if err := p.check(id); err != nil {
+			return err
+		}
+	}
+	p.root = id
+	p.dirty = true
+	return nil
+}
+
+func (p *Pager) check(id PageID) error {
+	if id == 0 || uint64(id) >= p.pageCount {
+		return fmt.Errorf("%w: %d", ErrPageID, id)
+	}
+	return nil
+}
+
+// Allocate returns a page for the caller to write, reusing a freed page if there is
+// one. a new page at the end of the file is zeroed, a reused one holds old bytes
+func (p *Pager) Allocate() (PageID, error) {
+	if p.free != 0 {
+		id := p.free
+		page := make([]byte, p.pageSize)
+		if err := p.Read(id, page); err != nil {
+			return 0, err
+		}
+		next := PageID(binary.LittleEndian.Uint64(page))
+		if next != 0 && p.check(next) != nil {
+			return 0, fmt.Errorf("%w: free list of %d points to %d", ErrPageID, id, next)
+		}
+		p.free = next
+		p.dirty = true
+		return id, nil
+	}
+	id := PageID(p.pageCount)
+	if _, err := p.f.WriteAt(make([]byte, p.pageSize), p.offset(id)); err != nil {
+		return 0, err
+	}
+	p.pageCount++
+	p.dirty = true
+	return id, nil
+}
+
+// Free puts id on the free list, overwriting its first 8 bytes. a page must not be freed
+// again before Allocate hands it back: the list would loop through it and Allocate would
+// hand it out twice. only the page freed last is caught, finding the others would mean
+// reading the whole list
+func (p *Pager) Free(id PageID) error {
+	if err := p.check(id); err != nil {
+		return err
+	}
+	if id == p.free {
+		return fmt.Errorf("%w: %d", ErrFreed, id)
+	}
+	page := make([]byte, p.pageSize)
+	binary.LittleEndian.PutUint64(page, uint64(p.free))
+	if err := p.Write(id, page); err != nil {
+		return err
+	}
+	p.free = id
+	p.dirty = true
+	return nil
+}
+
+func (p *Pager) offset(id PageID) int64 {
+	return int64(id) * int64(p.pageSize)
+}
+
+// Read fills page, which must be exactly PageSize bytes, with the contents of id
+func (p *Pager) Read(id PageID, page []byte) error {
+	if err := p.check(id); err != nil {
+		return err
+	}
+	if len(page) != p.pageSize {
+		return fmt.Errorf("%w: buffer is %d bytes, pages are %d", ErrPageSize, len(page), p.pageSize)
+	}
+	_, err := p.f.ReadAt(page, p.offset(id))
+	return err
+}
+
+// Write stores page, which must be exactly PageSize bytes, as the contents of id
+func (p *Pager) Write(id PageID, page []byte) error {
+	if err := p.check(id); err != nil {
+		return err
+	}
+	if len(page) != p.pageSize {
+		return fmt.Errorf("%w: buffer is %d bytes, pages are %d", ErrPageSize, len(page), p.pageSize)
+	}
+	_, err := p.f.WriteAt(page, p.offset(id))
+	return err
+}
+
+// Sync writes the header if it changed, then flushes the file to disk
+func (p *Pager) Sync() error {
+	if p.dirty {
+		if err := p.writeHeader(); err != nil {
+			return err
+		}
+		p.dirty = false
+	}
+	return p.f.Sync()
+}
+
+// Close syncs and closes the file
+func (p *Pager) Close() error {
+	err := p.Sync()
+	if cerr := p.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
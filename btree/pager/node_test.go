@@ -0,0 +1,156 @@
+package pager
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/a-tk/go-datastructures/btree_mem"
+)
+
+var layout = Layout[int, string]{Keys: btree_mem.Varint[int]{}, Vals: btree_mem.String[string]{}}
+
+func TestLayout_RoundTrip(t *testing.T) {
+	nodes := []*Node[int, string]{
+		{Leaf: true, Keys: []int{}, Vals: []string{}},
+		{Leaf: true, Keys: []int{-5, 0, 300}, Vals: []string{"a", "", "long value"}},
+		{Leaf: false, Keys: []int{10, 20}, Vals: []string{"x", "y"}, Children: []PageID{3, 9, 4}},
+	}
+	page := make([]byte, 256)
+	for _, n := range nodes {
+		// stale bytes from a previous node must not leak into the next
+		for i := range page {
+			page[i] = 0xee
+		}
+		if err := layout.Encode(page, n); err != nil {
+			t.Fatal(err)
+		}
+		got, err := layout.Decode(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Leaf {
+			n.Children = nil
+		}
+		if !reflect.DeepEqual(got, n) {
+			t.Errorf("decoded %+v, expected %+v", got, n)
+		}
+		// Size is exact, a page one byte smaller does not fit
+		size := layout.Size(n)
+		if err := layout.Encode(make([]byte, size), n); err != nil {
+			t.Errorf("node of Size %d does not fit in %d bytes: %v", size, size, err)
+		}
+		if err := layout.Encode(make([]byte, size-1), n); !errors.Is(err, ErrNodeTooLarge) {
+			t.Errorf("node of Size %d fits in %d bytes", size, size-1)
+		}
+	}
+}
+
+func TestLayout_Errors(t *testing.T) {
+	page := make([]byte, 128)
+	big := &Node[int, string]{Leaf: true, Keys: []int{1, 2}, Vals: []string{strings.Repeat("v", 60), strings.Repeat("w", 60)}}
+	if err := layout.Encode(page, big); !errors.Is(err, ErrNodeTooLarge) {
+		t.Errorf("oversized node should fail with ErrNodeTooLarge, got %v", err)
+	}
+	if size := layout.Size(big); size <= len(page) {
+		t.Errorf("Size of the oversized node is %d", size)
+	}
+	// a failed Encode leaves the page alone, and the bytes past it, even when there is
+	// room in the array
+	backing := make([]byte, 256)
+	old := &Node[int, string]{Leaf: true, Keys: []int{7}, Vals: []string{"old"}}
+	if err := layout.Encode(backing[:128], old); err != nil {
+		t.Fatal(err)
+	}
+	before := slices.Clone(backing)
+	if err := layout.Encode(backing[:128], big); !errors.Is(err, ErrNodeTooLarge) {
+		t.Errorf("oversized node should fail with ErrNodeTooLarge, got %v", err)
+	}
+	if !bytes.Equal(backing, before) {
+		t.Fatalf("a failed Encode changed the page")
+	}
+	bad := &Node[int, string]{Leaf: false, Keys: []int{1}, Vals: []string{"a"}, Children: []PageID{2}}
+	if err := layout.Encode(page, bad); err == nil {
+		t.Errorf("internal node with too few children should fail")
+	}
+
+	n := &Node[int, string]{Leaf: true, Keys: []int{1}, Vals: []string{"a"}}
+	if err := layout.Encode(page, n); err != nil {
+		t.Fatal(err)
+	}
+	page[5] ^= 1
+	if _, err := layout.Decode(page); !errors.Is(err, ErrBadNode) {
+		t.Errorf("damaged page should fail with ErrBadNode, got %v", err)
+	}
+	if _, err := layout.Decode(make([]byte, 128)); !errors.Is(err, ErrBadNode) {
+		t.Errorf("zeroed page should fail with ErrBadNode, got %v", err)
+	}
+}
+
+// a two level tree written through the pager and found again from the header after reopening
+func TestLayout_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	p, err := Open(path, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := make([]byte, p.PageSize())
+	write := func(n *Node[int, string]) PageID {
+		id, err := p.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := layout.Encode(page, n); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Write(id, page); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	l := write(&Node[int, string]{Leaf: true, Keys: []int{1, 2}, Vals: []string{"one", "two"}})
+	r := write(&Node[int, string]{Leaf: true, Keys: []int{4, 5}, Vals: []string{"four", "five"}})
+	root := write(&Node[int, string]{Keys: []int{3}, Vals: []string{"three"}, Children: []PageID{l, r}})
+	p.SetRoot(root)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	read := func(id PageID) *Node[int, string] {
+		if err := p.Read(id, page); err != nil {
+			t.Fatal(err)
+		}
+		n, err := layout.Decode(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	var got []string
+	var walk func(id PageID)
+	walk = func(id PageID) {
+		n := read(id)
+		for i := range n.Keys {
+			if !n.Leaf {
+				walk(n.Children[i])
+			}
+			got = append(got, n.Vals[i])
+		}
+		if !n.Leaf {
+			walk(n.Children[len(n.Keys)])
+		}
+	}
+	walk(p.Root())
+	if strings.Join(got, " ") != "one two three four five" {
+		t.Errorf("walking the reopened tree gave %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package pager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func filled(size int, b byte) []byte {
+	return bytes.Repeat([]byte{b}, size)
+}
+
+func TestPager_ReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	p, err := Open(path, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PageSize() != 256 || p.PageCount() != 1 || p.Root() != 0 {
+		t.Errorf("new file has page size %d, %d pages and root %d", p.PageSize(), p.PageCount(), p.Root())
+	}
+	var ids []PageID
+	for i := 0; i < 5; i++ {
+		id, err := p.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != PageID(i+1) {
+			t.Errorf("allocation %d returned page %d", i, id)
+		}
+		if err := p.Write(id, filled(256, byte(i+1))); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := p.SetRoot(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Free(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Free(ids[3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// everything survives reopening, and 0 takes the file's page size
+	p, err = Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if p.PageSize() != 256 || p.PageCount() != 6 || p.Root() != ids[2] {
+		t.Errorf("reopened file has page size %d, %d pages and root %d", p.PageSize(), p.PageCount(), p.Root())
+	}
+	page := make([]byte, 256)
+	for _, i := range []int{0, 2, 4} {
+		if err := p.Read(ids[i], page); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(page, filled(256, byte(i+1))) {
+			t.Errorf("page %d does not hold what was written", ids[i])
+		}
+	}
+	// freed pages come back last in, first out before the file grows
+	for _, want := range []PageID{ids[3], ids[1], 6} {
+		id, err := p.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != want {
+			t.Errorf("Allocate returned %d, expected %d", id, want)
+		}
+	}
+	if p.PageCount() != 7 {
+		t.Errorf("page count is %d, expected 7", p.PageCount())
+	}
+}
+
+func TestPager_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tree.db")
+	if _, err := Open(path, 64); !errors.Is(err, ErrPageSize) {
+		t.Errorf("page size 64 should fail with ErrPageSize, got %v", err)
+	}
+	p, err := Open(path, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := p.Allocate()
+	page := make([]byte, 512)
+	if err := p.Read(0, page); !errors.Is(err, ErrPageID) {
+		t.Errorf("reading the header page should fail with ErrPageID, got %v", err)
+	}
+	if err := p.Write(id+1, page); !errors.Is(err, ErrPageID) {
+		t.Errorf("writing past the end should fail with ErrPageID, got %v", err)
+	}
+	if err := p.Read(id, page[:100]); !errors.Is(err, ErrPageSize) {
+		t.Errorf("a short buffer should fail with ErrPageSize, got %v", err)
+	}
+	if err := p.SetRoot(id + 5); !errors.Is(err, ErrPageID) {
+		t.Errorf("a root past the end should fail with ErrPageID, got %v", err)
+	}
+	if err := p.Free(0); !errors.Is(err, ErrPageID) {
+		t.Errorf("freeing the header page should fail with ErrPageID, got %v", err)
+	}
+	if err := p.Free(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Free(id); !errors.Is(err, ErrFreed) {
+		t.Errorf("freeing a page twice should fail with ErrFreed, got %v", err)
+	}
+	if got, _ := p.Allocate(); got != id {
+		t.Errorf("Allocate returned %d, expected the freed page %d", got, id)
+	}
+	if got, _ := p.Allocate(); got == id {
+		t.Errorf("Allocate handed out page %d twice", id)
+	}
+	p.Close()
+
+	if _, err := Open(path, 4096); !errors.Is(err, ErrPageSize) {
+		t.Errorf("reopening with another page size should fail with ErrPageSize, got %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	data[20] ^= 1
+	bad := filepath.Join(dir, "bad.db")
+	os.WriteFile(bad, data, 0o644)
+	if _, err := Open(bad, 0); !errors.Is(err, ErrBadHeader) {
+		t.Errorf("a damaged header should fail with ErrBadHeader, got %v", err)
+	}
+
+	other := filepath.Join(dir, "other.txt")
+	os.WriteFile(other, []byte("not a page file at all, just some text"), 0o644)
+	if _, err := Open(other, 0); !errors.Is(err, ErrBadHeader) {
+		t.Errorf("a short file should fail with ErrBadHeader, got %v", err)
+	}
+}
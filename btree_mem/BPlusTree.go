@@ -0,0 +1,443 @@
+package btree_mem
+
+import (
+	"fmt"
+	"iter"
+)
+
+// in a B+ tree every value lives in a leaf and the internal nodes only hold copies of
+// keys to route searches. leaves are chained left to right, so a scan walks the chain
+// and never climbs back through the internal nodes
+//
+// the separators follow: every key in children[i] < keys[i] <= every key in children[i+1].
+// a separator may outlive the key it was copied from after a delete, which is still correct
+type bpnode[K any, V any] struct {
+	n        int
+	leaf     bool
+	keys     []K
+	vals     []V // leaves only
+	children []*bpnode[K, V]
+	next     *bpnode[K, V] // leaves only, the leaf to the right
+}
+
+type BPlusTree[K any, V any] struct {
+	root    *bpnode[K, V]
+	degree  int
+	height  int
+	size    int
+	compare func(K, K) int
+}
+
+func newLeaf[K any, V any](t int) *bpnode[K, V] {
+	return &bpnode[K, V]{
+		n:    0,
+		leaf: true,
+		keys: make([]K, 2*t-1),
+		vals: make([]V, 2*t-1),
+	}
+}
+
+func newInternal[K any, V any](t int) *bpnode[K, V] {
+	return &bpnode[K, V]{
+		n:        0,
+		leaf:     false,
+		keys:     make([]K, 2*t-1),
+		children: make([]*bpnode[K, V], 2*t),
+	}
+}
+
+// NewBPlus takes the same degree as New, every node other than the root holds
+// between degree-1 and 2*degree-1 keys
+func NewBPlus[K any, V any](degree int, compare func(K, K) int) *BPlusTree[K, V] {
+	return &BPlusTree[K, V]{
+		degree:  degree,
+		root:    newLeaf[K, V](degree),
+		height:  0,
+		size:    0,
+		compare: compare,
+	}
+}
+
+// position finds the index of k in the first n keys of x, or the index of the first key greater than k
+func (b *BPlusTree[K, V]) position(x *bpnode[K, V], k K) (int, bool) {
+	low := 0
+	high := x.n - 1
+	for low <= high {
+		mid := low + (high-low)/2
+		cmp := b.compare(x.keys[mid], k)
+		if cmp == 0 {
+			return mid, true
+		} else if cmp < 0 {
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, false
+}
+
+// child finds the child of the internal node x that would hold k. a separator equal
+// to k sends the search right
+func (b *BPlusTree[K, V]) child(x *bpnode[K, V], k K) int {
+	i, found := b.position(x, k)
+	if found {
+		i++
+	}
+	return i
+}
+
+// leafFor finds the leaf that would hold k
+func (b *BPlusTree[K, V]) leafFor(k K) *bpnode[K, V] {
+	x := b.root
+	for !x.leaf {
+		x = x.children[b.child(x, k)]
+	}
+	return x
+}
+
+func (b *BPlusTree[K, V]) Search(k K) (val V, found bool) {
+	x := b.leafFor(k)
+	if i, found := b.position(x, k); found {
+		return x.vals[i], true
+	}
+	return val, false
+}
+
+// Insert splits full nodes on the way down, like BTree.Insert, so the leaf always has room
+func (b *BPlusTree[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	if b.root.n == 2*b.degree-1 {
+		s := newInternal[K, V](b.degree)
+		s.children[0] = b.root
+		b.root = s
+		b.splitChild(s, 0)
+		b.height++
+	}
+	x := b.root
+	for !x.leaf {
+		i := b.child(x, k)
+		if x.children[i].n == 2*b.degree-1 {
+			b.splitChild(x, i)
+			if b.compare(k, x.keys[i]) >= 0 {
+				i++
+			}
+		}
+		x = x.children[i]
+	}
+
+	i, found := b.position(x, k)
+	if found {
+		old = x.vals[i]
+		x.vals[i] = v
+		return old, true
+	}
+	for j := x.n; j > i; j-- {
+		x.keys[j] = x.keys[j-1]
+		x.vals[j] = x.vals[j-1]
+	}
+	x.keys[i] = k
+	x.vals[i] = v
+	x.n++
+	b.size++
+	return old, false
+}
+
+// splitChild splits the full child i of x. a leaf keeps its first t-1 entries and a copy
+// of the first key of the new leaf goes up, since the entry itself must stay in a leaf.
+// an internal node gives up its median, as in a B-tree
+func (b *BPlusTree[K, V]) splitChild(x *bpnode[K, V], i int) {
+	t := b.degree
+	y := x.children[i]
+	var z *bpnode[K, V]
+	var sep K
+	if y.leaf {
+		z = newLeaf[K, V](t)
+		z.n = t
+		copy(z.keys, y.keys[t-1:2*t-1])
+		copy(z.vals, y.vals[t-1:2*t-1])
+		clear(y.keys[t-1 : 2*t-1])
+		clear(y.vals[t-1 : 2*t-1])
+		z.next = y.next
+		y.next = z
+		sep = z.keys[0]
+	} else {
+		z = newInternal[K, V](t)
+		z.n = t - 1
+		copy(z.keys, y.keys[t:2*t-1])
+		copy(z.children, y.children[t:2*t])
+		sep = y.keys[t-1]
+		clear(y.keys[t-1 : 2*t-1])
+		clear(y.children[t : 2*t])
+	}
+	y.n = t - 1
+
+	for j := x.n; j > i; j-- {
+		x.keys[j] = x.keys[j-1]
+		x.children[j+1] = x.children[j]
+	}
+	x.keys[i] = sep
+	x.children[i+1] = z
+	x.n++
+}
+
+// Delete removes k in a single pass down the tree. like BTree.Delete, every child is
+// given a spare key before the search enters it, so the leaf can always lose one
+func (b *BPlusTree[K, V]) Delete(k K) (old V, found bool) {
+	t := b.degree
+	x := b.root
+	for !x.leaf {
+		i := b.child(x, k)
+		if x.children[i].n == t-1 {
+			if i > 0 && x.children[i-1].n >= t {
+				b.borrowLeft(x, i)
+			} else if i < x.n && x.children[i+1].n >= t {
+				b.borrowRight(x, i)
+			} else if i < x.n {
+				b.merge(x, i)
+			} else {
+				b.merge(x, i-1)
+				i--
+			}
+		}
+		x = x.children[i]
+	}
+
+	i, found := b.position(x, k)
+	if !found {
+		b.shrink()
+		return old, false
+	}
+	old = x.vals[i]
+	for j := i; j < x.n-1; j++ {
+		x.keys[j] = x.keys[j+1]
+		x.vals[j] = x.vals[j+1]
+	}
+	x.n--
+	var zk K
+	var zv V
+	x.keys[x.n] = zk
+	x.vals[x.n] = zv
+	b.size--
+	b.shrink()
+	return old, true
+}
+
+// shrink drops an internal root left with no keys by a merge, its only child takes its place
+func (b *BPlusTree[K, V]) shrink() {
+	if !b.root.leaf && b.root.n == 0 {
+		b.root = b.root.children[0]
+		b.height--
+	}
+}
+
+// borrowLeft moves the last entry of child i-1 to the front of child i
+func (b *BPlusTree[K, V]) borrowLeft(x *bpnode[K, V], i int) {
+	c := x.children[i]
+	s := x.children[i-1]
+	for j := c.n; j > 0; j-- {
+		c.keys[j] = c.keys[j-1]
+	}
+	var zk K
+	if c.leaf {
+		for j := c.n; j > 0; j-- {
+			c.vals[j] = c.vals[j-1]
+		}
+		c.keys[0] = s.keys[s.n-1]
+		c.vals[0] = s.vals[s.n-1]
+		var zv V
+		s.vals[s.n-1] = zv
+		x.keys[i-1] = c.keys[0]
+	} else {
+		for j := c.n + 1; j > 0; j-- {
+			c.children[j] = c.children[j-1]
+		}
+		c.keys[0] = x.keys[i-1]
+		c.children[0] = s.children[s.n]
+		s.children[s.n] = nil
+		x.keys[i-1] = s.keys[s.n-1]
+	}
+	s.keys[s.n-1] = zk
+	c.n++
+	s.n--
+}
+
+// borrowRight moves the first entry of child i+1 to the end of child i
+func (b *BPlusTree[K, V]) borrowRight(x *bpnode[K, V], i int) {
+	c := x.children[i]
+	s := x.children[i+1]
+	if c.leaf {
+		c.keys[c.n] = s.keys[0]
+		c.vals[c.n] = s.vals[0]
+		for j := 0; j < s.n-1; j++ {
+			s.keys[j] = s.keys[j+1]
+			s.vals[j] = s.vals[j+1]
+		}
+		var zv V
+		s.vals[s.n-1] = zv
+		x.keys[i] = s.keys[0]
+	} else {
+		c.keys[c.n] = x.keys[i]
+		c.children[c.n+1] = s.children[0]
+		x.keys[i] = s.keys[0]
+		for j := 0; j < s.n-1; j++ {
+			s.keys[j] = s.keys[j+1]
+		}
+		for j := 0; j < s.n; j++ {
+			s.children[j] = s.children[j+1]
+		}
+		s.children[s.n] = nil
+	}
+	var zk K
+	s.keys[s.n-1] = zk
+	c.n++
+	s.n--
+}
+
+// merge folds child i+1 into child i and removes their separator from x. leaves drop
+// the separator, it was only a copy, internal nodes pull it down between the two
+func (b *BPlusTree[K, V]) merge(x *bpnode[K, V], i int) {
+	y := x.children[i]
+	z := x.children[i+1]
+	if y.leaf {
+		copy(y.keys[y.n:], z.keys[:z.n])
+		copy(y.vals[y.n:], z.vals[:z.n])
+		y.n += z.n
+		y.next = z.next
+	} else {
+		y.keys[y.n] = x.keys[i]
+		copy(y.keys[y.n+1:], z.keys[:z.n])
+		copy(y.children[y.n+1:], z.children[:z.n+1])
+		y.n += z.n + 1
+	}
+
+	for j := i; j < x.n-1; j++ {
+		x.keys[j] = x.keys[j+1]
+	}
+	for j := i + 1; j < x.n; j++ {
+		x.children[j] = x.children[j+1]
+	}
+	var zk K
+	x.keys[x.n-1] = zk
+	x.children[x.n] = nil
+	x.n--
+}
+
+func (b *BPlusTree[K, V]) Height() int {
+	return b.height
+}
+
+func (b *BPlusTree[K, V]) Size() int {
+	return b.size
+}
+
+func (b *BPlusTree[K, V]) Degree() int {
+	return b.degree
+}
+
+// All returns an iterator over every key and value in ascending order, walking the leaf chain
+func (b *BPlusTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		x := b.root
+		for !x.leaf {
+			x = x.children[0]
+		}
+		b.scan(x, 0, nil, yield)
+	}
+}
+
+// Range returns an iterator over the keys k where lo <= k < hi, in ascending order.
+// one descent finds the leaf holding lo, then the scan follows the leaf chain
+func (b *BPlusTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		x := b.leafFor(lo)
+		i, _ := b.position(x, lo)
+		b.scan(x, i, &hi, yield)
+	}
+}
+
+// scan yields from entry i of leaf x onwards, stopping before hi when it is not nil
+func (b *BPlusTree[K, V]) scan(x *bpnode[K, V], i int, hi *K, yield func(K, V) bool) {
+	for ; x != nil; x, i = x.next, 0 {
+		for ; i < x.n; i++ {
+			if hi != nil && b.compare(x.keys[i], *hi) >= 0 {
+				return
+			}
+			if !yield(x.keys[i], x.vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Validate checks the key counts and ordering as BTree.Validate does, that separators
+// bound their children and that the leaf chain visits every leaf in order
+func (b *BPlusTree[K, V]) Validate() error {
+	if b.root == nil {
+		return &ValidationError{Path: "root", Reason: "root is nil"}
+	}
+	if !b.root.leaf && b.root.n == 0 {
+		return &ValidationError{Path: "root", Reason: "internal root has no keys"}
+	}
+	var leaves []*bpnode[K, V]
+	count, err := b.validate(b.root, nil, nil, 0, "root", &leaves)
+	if err != nil {
+		return err
+	}
+	if count != b.size {
+		return &ValidationError{Path: "root", Reason: fmt.Sprintf("size is %d but the tree has %d keys", b.size, count)}
+	}
+	for i, x := range leaves {
+		var want *bpnode[K, V]
+		if i+1 < len(leaves) {
+			want = leaves[i+1]
+		}
+		if x.next != want {
+			return &ValidationError{Path: fmt.Sprintf("leaf %d", i), Reason: "next does not point to the following leaf"}
+		}
+	}
+	return nil
+}
+
+// validate checks the subtree at x, where every key must be >= lo and < hi when they
+// are not nil, collects its leaves in order and returns the number of entries in it
+func (b *BPlusTree[K, V]) validate(x *bpnode[K, V], lo, hi *K, depth int, path string, leaves *[]*bpnode[K, V]) (int, error) {
+	t := b.degree
+	if x.n > 2*t-1 || (x != b.root && x.n < t-1) {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("%d keys is outside [%d, %d]", x.n, t-1, 2*t-1)}
+	}
+	for i := 0; i < x.n; i++ {
+		k := x.keys[i]
+		if i > 0 && b.compare(x.keys[i-1], k) >= 0 {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("keys %d and %d are not sorted", i-1, i)}
+		}
+		if lo != nil && b.compare(k, *lo) < 0 || hi != nil && b.compare(k, *hi) >= 0 {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("key %v is outside the range of its parent", k)}
+		}
+	}
+	if x.leaf {
+		if depth != b.height {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("leaf is at depth %d, height is %d", depth, b.height)}
+		}
+		*leaves = append(*leaves, x)
+		return x.n, nil
+	}
+	count := 0
+	for i := 0; i <= x.n; i++ {
+		child := fmt.Sprintf("%s/%d", path, i)
+		if x.children[i] == nil {
+			return 0, &ValidationError{Path: child, Reason: "internal node is missing a child"}
+		}
+		l, h := lo, hi
+		if i > 0 {
+			l = &x.keys[i-1]
+		}
+		if i < x.n {
+			h = &x.keys[i]
+		}
+		c, err := b.validate(x.children[i], l, h, depth+1, child, leaves)
+		if err != nil {
+			return 0, err
+		}
+		count += c
+	}
+	return count, nil
+}
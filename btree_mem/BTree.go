@@ -1,5 +1,11 @@
 package btree_mem
 
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
 // here, an array of pointers offers more advantages than direct object storage in an array
 // for sparse trees, 50% space in the arrays may be wasted
 // this consideration is only for trees held entirely in memory
@@ -8,12 +14,18 @@ type container[K any, V any] struct {
 	val V
 }
 
-// TODO: delete :(
 type node[K any, V any] struct {
 	n        int
 	leaf     bool
 	keys     []container[K, V]
 	children []*node[K, V]
+	owner    *owner // the tree allowed to modify this node in place, see Clone
+}
+
+// owner marks which tree a node belongs to. it is not empty, since pointers to
+// distinct zero size values are allowed to be equal
+type owner struct {
+	_ byte
 }
 
 type BTree[K any, V any] struct {
@@ -22,37 +34,106 @@ type BTree[K any, V any] struct {
 	height  int
 	size    int
 	compare func(K, K) int
+	owner   *owner
+	// used by WriteTo and ReadFrom, see SetCodecs
+	keyCodec Codec[K]
+	valCodec Codec[V]
 }
 
-func newNode[K any, V any](t int) *node[K, V] {
+func newNode[K any, V any](t int, o *owner) *node[K, V] {
 	return &node[K, V]{
 		n:        0,
 		leaf:     true,
 		keys:     make([]container[K, V], 2*t-1),
 		children: make([]*node[K, V], 2*t),
+		owner:    o,
 	}
 }
 
 func New[K any, V any](degree int, compare func(K, K) int) (b *BTree[K, V]) {
+	o := &owner{}
 	return &BTree[K, V]{
 		degree:  degree,
-		root:    newNode[K, V](degree),
+		root:    newNode[K, V](degree, o),
+		owner:   o,
 		height:  0,
 		size:    0,
 		compare: compare,
 	}
 }
 
+// ErrUnsorted is returned by FromSorted when the keys are not strictly increasing
+var ErrUnsorted = errors.New("btree_mem: input is not sorted")
+
+// FromSorted builds a tree in O(n) from seq, which must yield strictly increasing keys.
+// nodes are built bottom up, one level at a time, and packed as full as the
+// minimum of t-1 keys per node allows, where repeated Insert leaves most nodes half full
+func FromSorted[K any, V any](degree int, compare func(K, K) int, seq iter.Seq2[K, V]) (*BTree[K, V], error) {
+	b := New[K, V](degree, compare)
+	var keys []container[K, V]
+	for k, v := range seq {
+		if n := len(keys); n > 0 && compare(keys[n-1].key, k) >= 0 {
+			return nil, fmt.Errorf("%w: key %v at position %d does not follow %v", ErrUnsorted, k, n, keys[n-1].key)
+		}
+		keys = append(keys, container[K, V]{key: k, val: v})
+	}
+	b.size = len(keys)
+	level, seps := b.buildLevel(keys, nil)
+	for len(level) > 1 {
+		level, seps = b.buildLevel(seps, level)
+		b.height++
+	}
+	if len(level) == 1 {
+		b.root = level[0]
+	}
+	return b, nil
+}
+
+// buildLevel spreads keys over as few nodes as possible, with one key left over
+// between each pair of nodes to separate them in the level above. when children is
+// not nil it holds len(keys)+1 nodes from the level below, given out in order.
+// k nodes hold at most 2t-1 keys each plus k-1 separators, so k = ceil((n+1)/2t).
+// spread evenly that leaves every node at least t-1 keys when k > 1
+func (b *BTree[K, V]) buildLevel(keys []container[K, V], children []*node[K, V]) ([]*node[K, V], []container[K, V]) {
+	t := b.degree
+	n := len(keys)
+	if n == 0 && children == nil {
+		return nil, nil
+	}
+	k := (n + 2*t) / (2 * t)
+	nodes := make([]*node[K, V], k)
+	seps := make([]container[K, V], 0, k-1)
+	per, extra := (n-(k-1))/k, (n-(k-1))%k
+	for j := range nodes {
+		m := per
+		if j < extra {
+			m++
+		}
+		x := newNode[K, V](t, b.owner)
+		x.n = m
+		copy(x.keys, keys[:m])
+		keys = keys[m:]
+		if children != nil {
+			x.leaf = false
+			copy(x.children, children[:m+1])
+			children = children[m+1:]
+		}
+		if j < k-1 {
+			seps = append(seps, keys[0])
+			keys = keys[1:]
+		}
+		nodes[j] = x
+	}
+	return nodes, seps
+}
+
 func (b *BTree[K, V]) Search(k K) (val V, found bool) {
 	return b.search(b.root, k)
 }
 
 func (b *BTree[K, V]) search(x *node[K, V], k K) (val V, found bool) {
-	i := 0
-	for i < x.n && b.compare(x.keys[i].key, k) < 1 {
-		i++
-	}
-	if i < x.n && b.compare(x.keys[i].key, k) == 0 {
+	i, found := b.bsearch(x.keys, k, x.n)
+	if found {
 		return x.keys[i].val, true
 	} else if x.leaf {
 		return val, false
@@ -62,6 +143,7 @@ func (b *BTree[K, V]) search(x *node[K, V], k K) (val V, found bool) {
 }
 
 func (b *BTree[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	b.root = b.mutable(b.root)
 	r := b.root
 	if r.n == 2*b.degree-1 {
 		s := b.splitRoot()
@@ -105,6 +187,7 @@ func (b *BTree[K, V]) insertNonFull(x *node[K, V], k K, v V) (old V, replaced bo
 		i = i + 1
 
 		if x.children[i].n == 2*b.degree-1 {
+			b.mutableChild(x, i)
 			b.splitChild(x, i)
 			// which child to insert into?
 			if b.compare(x.keys[i].key, k) < 0 {
@@ -115,14 +198,14 @@ func (b *BTree[K, V]) insertNonFull(x *node[K, V], k K, v V) (old V, replaced bo
 				return previous, true
 			}
 		}
-		return b.insertNonFull(x.children[i], k, v)
+		return b.insertNonFull(b.mutableChild(x, i), k, v)
 	}
 }
 
 func (b *BTree[K, V]) splitChild(x *node[K, V], i int) {
 	t := b.degree
 	y := x.children[i]
-	z := newNode[K, V](t)
+	z := newNode[K, V](t, b.owner)
 	z.leaf = y.leaf
 	z.n = t - 1
 	for j := 0; j <= t-2; j++ {
@@ -157,7 +240,7 @@ func (b *BTree[K, V]) splitChild(x *node[K, V], i int) {
 }
 
 func (b *BTree[K, V]) splitRoot() *node[K, V] {
-	s := newNode[K, V](b.degree)
+	s := newNode[K, V](b.degree, b.owner)
 	s.leaf = false
 	s.n = 0
 	s.children[0] = b.root
@@ -167,6 +250,218 @@ func (b *BTree[K, V]) splitRoot() *node[K, V] {
 	return s
 }
 
+// Delete removes k in a single pass down the tree (CLRS 18.3). before descending into
+// a child with only t-1 keys, the child borrows a key through x from a sibling, or is
+// merged with one, so the key can always be removed from a leaf without going back up
+func (b *BTree[K, V]) Delete(k K) (old V, found bool) {
+	b.root = b.mutable(b.root)
+	old, found = b.delete(b.root, k)
+	if found {
+		b.size--
+	}
+	// merging the root's last two children leaves it empty, the merged child takes its place
+	if b.root.n == 0 && !b.root.leaf {
+		b.root = b.root.children[0]
+		b.height--
+	}
+	return old, found
+}
+
+func (b *BTree[K, V]) delete(x *node[K, V], k K) (old V, found bool) {
+	t := b.degree
+	i := b.iterativeBSearch(x.keys, k, x.n)
+	if i != -1 {
+		old = x.keys[i].val
+		if x.leaf {
+			// case 1, k is in a leaf
+			for j := i; j < x.n-1; j++ {
+				x.keys[j] = x.keys[j+1]
+			}
+			x.n--
+			x.keys[x.n] = container[K, V]{}
+			return old, true
+		}
+		if x.children[i].n >= t {
+			// case 2a, the predecessor takes the place of k and is removed below
+			pred := b.max(x.children[i])
+			x.keys[i] = pred
+			b.delete(b.mutableChild(x, i), pred.key)
+			return old, true
+		}
+		if x.children[i+1].n >= t {
+			// case 2b, same with the successor
+			succ := b.min(x.children[i+1])
+			x.keys[i] = succ
+			b.delete(b.mutableChild(x, i+1), succ.key)
+			return old, true
+		}
+		// case 2c, both neighbours are minimal, k moves down into their merge
+		b.merge(x, i)
+		return b.delete(b.mutableChild(x, i), k)
+	}
+	if x.leaf {
+		return old, false
+	}
+	i = 0
+	for i < x.n && b.compare(x.keys[i].key, k) < 0 {
+		i++
+	}
+	// case 3, make sure the child has a key to spare before descending
+	if x.children[i].n == t-1 {
+		if i > 0 && x.children[i-1].n >= t {
+			b.borrowLeft(x, i)
+		} else if i < x.n && x.children[i+1].n >= t {
+			b.borrowRight(x, i)
+		} else if i < x.n {
+			b.merge(x, i)
+		} else {
+			b.merge(x, i-1)
+			i--
+		}
+	}
+	return b.delete(b.mutableChild(x, i), k)
+}
+
+// merge moves key i of x and all of child i+1 into child i, both of which have t-1 keys
+func (b *BTree[K, V]) merge(x *node[K, V], i int) {
+	y := b.mutableChild(x, i)
+	z := x.children[i+1] // only read, then dropped
+	y.keys[y.n] = x.keys[i]
+	for j := 0; j < z.n; j++ {
+		y.keys[y.n+1+j] = z.keys[j]
+	}
+	if !y.leaf {
+		for j := 0; j <= z.n; j++ {
+			y.children[y.n+1+j] = z.children[j]
+		}
+	}
+	y.n = y.n + 1 + z.n
+
+	for j := i; j < x.n-1; j++ {
+		x.keys[j] = x.keys[j+1]
+	}
+	for j := i + 1; j < x.n; j++ {
+		x.children[j] = x.children[j+1]
+	}
+	// as in splitChild, clear the vacated slots so they do not alias live values
+	x.keys[x.n-1] = container[K, V]{}
+	x.children[x.n] = nil
+	x.n--
+}
+
+// borrowLeft rotates a key from child i-1 through x into the front of child i
+func (b *BTree[K, V]) borrowLeft(x *node[K, V], i int) {
+	c := b.mutableChild(x, i)
+	s := b.mutableChild(x, i-1)
+	for j := c.n; j > 0; j-- {
+		c.keys[j] = c.keys[j-1]
+	}
+	c.keys[0] = x.keys[i-1]
+	if !c.leaf {
+		for j := c.n + 1; j > 0; j-- {
+			c.children[j] = c.children[j-1]
+		}
+		c.children[0] = s.children[s.n]
+		s.children[s.n] = nil
+	}
+	c.n++
+	x.keys[i-1] = s.keys[s.n-1]
+	s.keys[s.n-1] = container[K, V]{}
+	s.n--
+}
+
+// borrowRight rotates a key from child i+1 through x onto the end of child i
+func (b *BTree[K, V]) borrowRight(x *node[K, V], i int) {
+	c := b.mutableChild(x, i)
+	s := b.mutableChild(x, i+1)
+	c.keys[c.n] = x.keys[i]
+	if !c.leaf {
+		c.children[c.n+1] = s.children[0]
+	}
+	c.n++
+	x.keys[i] = s.keys[0]
+	for j := 0; j < s.n-1; j++ {
+		s.keys[j] = s.keys[j+1]
+	}
+	s.keys[s.n-1] = container[K, V]{}
+	if !s.leaf {
+		for j := 0; j < s.n; j++ {
+			s.children[j] = s.children[j+1]
+		}
+		s.children[s.n] = nil
+	}
+	s.n--
+}
+
+// min and max find the smallest and largest entries in the subtree x, which is not empty
+func (b *BTree[K, V]) min(x *node[K, V]) container[K, V] {
+	for !x.leaf {
+		x = x.children[0]
+	}
+	return x.keys[0]
+}
+
+func (b *BTree[K, V]) max(x *node[K, V]) container[K, V] {
+	for !x.leaf {
+		x = x.children[x.n]
+	}
+	return x.keys[x.n-1]
+}
+
+// PopMin removes and returns the smallest key
+func (b *BTree[K, V]) PopMin() (k K, v V, found bool) {
+	if b.size == 0 {
+		return k, v, false
+	}
+	e := b.min(b.root)
+	b.Delete(e.key)
+	return e.key, e.val, true
+}
+
+// PopMax removes and returns the largest key
+func (b *BTree[K, V]) PopMax() (k K, v V, found bool) {
+	if b.size == 0 {
+		return k, v, false
+	}
+	e := b.max(b.root)
+	b.Delete(e.key)
+	return e.key, e.val, true
+}
+
+// Clone returns a copy of the tree in O(1). the two trees share every node until one
+// of them writes to it, and the writer copies just that node first, so after a write
+// only the path from the root to the changed node has been copied. both trees give up
+// ownership of the shared nodes, so the original pays the same copying as the clone.
+// since neither tree writes to a shared node, the clone can be read by other goroutines
+// while the original is modified, as long as Clone returned before they started
+func (b *BTree[K, V]) Clone() *BTree[K, V] {
+	c := *b
+	b.owner = &owner{}
+	c.owner = &owner{}
+	return &c
+}
+
+// mutable returns x if this tree owns it, otherwise a copy of it that this tree owns.
+// the caller must put the copy in place of x. nodes loaded by ReadFrom are owned by no
+// tree and only as large as their keys, the copy is always full size
+func (b *BTree[K, V]) mutable(x *node[K, V]) *node[K, V] {
+	if x.owner == b.owner {
+		return x
+	}
+	y := newNode[K, V](b.degree, b.owner)
+	y.n = x.n
+	y.leaf = x.leaf
+	copy(y.keys, x.keys)
+	copy(y.children, x.children)
+	return y
+}
+
+// mutableChild makes child i of x writable, x must already be writable
+func (b *BTree[K, V]) mutableChild(x *node[K, V], i int) *node[K, V] {
+	x.children[i] = b.mutable(x.children[i])
+	return x.children[i]
+}
+
 func (b *BTree[K, V]) Height() int {
 	return b.height
 }
@@ -199,20 +494,184 @@ func (b *BTree[K, V]) traverse(x *node[K, V], action func(V)) {
 	}
 }
 
+// All returns an iterator over every key and value in ascending order
+func (b *BTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.ascend(b.root, nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the keys k where lo <= k < hi, in ascending order.
+// it seeks straight to lo instead of scanning from the smallest key
+func (b *BTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.ascend(b.root, &lo, &hi, yield)
+	}
+}
+
+// Descend returns an iterator over the keys k where lo < k <= hi, in descending order,
+// the mirror image of Range
+func (b *BTree[K, V]) Descend(hi, lo K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		b.descend(b.root, &hi, &lo, yield)
+	}
+}
+
+// ascend visits the subtree x in order, starting at lo and stopping before hi when
+// they are not nil. it returns false once the walk is over, either because hi was
+// reached or yield asked to stop
+func (b *BTree[K, V]) ascend(x *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	i, found := 0, false
+	if lo != nil {
+		i, found = b.bsearch(x.keys, *lo, x.n)
+	}
+	// keys in child i come before key i, but may still be >= lo
+	if !x.leaf && !found && !b.ascend(x.children[i], lo, hi, yield) {
+		return false
+	}
+	for ; i < x.n; i++ {
+		if hi != nil && b.compare(x.keys[i].key, *hi) >= 0 {
+			return false
+		}
+		if !yield(x.keys[i].key, x.keys[i].val) {
+			return false
+		}
+		// everything further right is past lo
+		if !x.leaf && !b.ascend(x.children[i+1], nil, hi, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// descend is ascend in reverse, starting at hi and stopping at lo
+func (b *BTree[K, V]) descend(x *node[K, V], hi, lo *K, yield func(K, V) bool) bool {
+	i, found := x.n, false
+	if hi != nil {
+		i, found = b.bsearch(x.keys, *hi, x.n)
+	}
+	// key i is past hi unless it is hi, but child i comes before it
+	if !found {
+		if !x.leaf && !b.descend(x.children[i], hi, lo, yield) {
+			return false
+		}
+		i--
+	}
+	for ; i >= 0; i-- {
+		if lo != nil && b.compare(x.keys[i].key, *lo) <= 0 {
+			return false
+		}
+		if !yield(x.keys[i].key, x.keys[i].val) {
+			return false
+		}
+		if !x.leaf && !b.descend(x.children[i], nil, lo, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// iterativeBSearch finds the index of k in the first n keys, or -1
 func (b *BTree[K, V]) iterativeBSearch(keys []container[K, V], k K, n int) int {
+	if i, found := b.bsearch(keys, k, n); found {
+		return i
+	}
+	return -1
+}
+
+// bsearch finds the index of k in the first n keys, or when k is missing, the index
+// of the first key greater than k, which is also the child that would hold k
+func (b *BTree[K, V]) bsearch(keys []container[K, V], k K, n int) (int, bool) {
 	low := 0
 	high := n - 1
 
 	for low <= high {
 		mid := low + (high-low)/2 // Prevents potential overflow compared to (low + high) / 2
 
-		if b.compare(keys[mid].key, k) == 0 {
-			return mid
-		} else if b.compare(keys[mid].key, k) < 0 {
+		// one call per probe, the comparison may be expensive
+		cmp := b.compare(keys[mid].key, k)
+		if cmp == 0 {
+			return mid, true
+		} else if cmp < 0 {
 			low = mid + 1
 		} else {
 			high = mid - 1
 		}
 	}
-	return -1
+	return low, false
+}
+
+// ValidationError describes a broken invariant and where it was found.
+// Path is the route from the root by child index, e.g. "root/0/2" is the third child of the first child
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("btree_mem: %s at %s", e.Reason, e.Path)
+}
+
+// Validate checks that every node other than the root holds between t-1 and 2t-1 keys,
+// keys are sorted within and across nodes, every leaf is at depth Height() and Size()
+// matches the number of keys. it returns the first problem found, or nil
+func (b *BTree[K, V]) Validate() error {
+	if b.root == nil {
+		return &ValidationError{Path: "root", Reason: "root is nil"}
+	}
+	if !b.root.leaf && b.root.n == 0 {
+		return &ValidationError{Path: "root", Reason: "internal root has no keys"}
+	}
+	count, err := b.validate(b.root, nil, nil, 0, "root")
+	if err != nil {
+		return err
+	}
+	if count != b.size {
+		return &ValidationError{Path: "root", Reason: fmt.Sprintf("size is %d but the tree has %d keys", b.size, count)}
+	}
+	return nil
+}
+
+// validate checks the subtree at x, where every key must be strictly between lo and hi
+// when they are not nil, and returns the number of keys in it
+func (b *BTree[K, V]) validate(x *node[K, V], lo, hi *K, depth int, path string) (int, error) {
+	t := b.degree
+	if x.n > 2*t-1 || (x != b.root && x.n < t-1) {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("%d keys is outside [%d, %d]", x.n, t-1, 2*t-1)}
+	}
+	for i := 0; i < x.n; i++ {
+		k := x.keys[i].key
+		if i > 0 && b.compare(x.keys[i-1].key, k) >= 0 {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("keys %d and %d are not sorted", i-1, i)}
+		}
+		if lo != nil && b.compare(k, *lo) <= 0 || hi != nil && b.compare(k, *hi) >= 0 {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("key %v is outside the range of its parent", k)}
+		}
+	}
+	if x.leaf {
+		if depth != b.height {
+			return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("leaf is at depth %d, height is %d", depth, b.height)}
+		}
+		return x.n, nil
+	}
+	count := x.n
+	for i := 0; i <= x.n; i++ {
+		child := fmt.Sprintf("%s/%d", path, i)
+		if x.children[i] == nil {
+			return 0, &ValidationError{Path: child, Reason: "internal node is missing a child"}
+		}
+		l, h := lo, hi
+		if i > 0 {
+			l = &x.keys[i-1].key
+		}
+		if i < x.n {
+			h = &x.keys[i].key
+		}
+		c, err := b.validate(x.children[i], l, h, depth+1, child)
+		if err != nil {
+			return 0, err
+		}
+		count += c
+	}
+	return count, nil
 }
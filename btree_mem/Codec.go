@@ -0,0 +1,81 @@
+package btree_mem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Codec turns keys or values into bytes for WriteTo and back for ReadFrom.
+// Decode is given exactly the bytes Append added for one value, in a buffer ReadFrom
+// reuses for the next one, so it must copy anything it keeps, such as a []byte
+type Codec[T any] interface {
+	Append(dst []byte, v T) []byte
+	Decode(src []byte) (T, error)
+}
+
+var errCodec = errors.New("btree_mem: malformed value")
+
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Varint encodes signed integers in 1 to 10 bytes, small magnitudes take fewer
+type Varint[T signed] struct{}
+
+func (Varint[T]) Append(dst []byte, v T) []byte {
+	return binary.AppendVarint(dst, int64(v))
+}
+
+func (Varint[T]) Decode(src []byte) (T, error) {
+	x, n := binary.Varint(src)
+	if n <= 0 || n != len(src) || int64(T(x)) != x {
+		return 0, fmt.Errorf("%w: bad varint", errCodec)
+	}
+	return T(x), nil
+}
+
+// Uvarint encodes unsigned integers in 1 to 10 bytes, small values take fewer
+type Uvarint[T unsigned] struct{}
+
+func (Uvarint[T]) Append(dst []byte, v T) []byte {
+	return binary.AppendUvarint(dst, uint64(v))
+}
+
+func (Uvarint[T]) Decode(src []byte) (T, error) {
+	x, n := binary.Uvarint(src)
+	if n <= 0 || n != len(src) || uint64(T(x)) != x {
+		return 0, fmt.Errorf("%w: bad uvarint", errCodec)
+	}
+	return T(x), nil
+}
+
+// String stores the bytes of a string as they are
+type String[T ~string] struct{}
+
+func (String[T]) Append(dst []byte, v T) []byte {
+	return append(dst, v...)
+}
+
+func (String[T]) Decode(src []byte) (T, error) {
+	return T(src), nil
+}
+
+// Float64 stores the IEEE 754 bits in 8 bytes
+type Float64 struct{}
+
+func (Float64) Append(dst []byte, v float64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+}
+
+func (Float64) Decode(src []byte) (float64, error) {
+	if len(src) != 8 {
+		return 0, fmt.Errorf("%w: float64 needs 8 bytes, got %d", errCodec, len(src))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(src)), nil
+}
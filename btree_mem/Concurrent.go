@@ -0,0 +1,546 @@
+package btree_mem
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Concurrent is a BTree that many goroutines can search and modify at once, using
+// latch crabbing: a goroutine latches a child before letting go of its parent, so it
+// never sees a node halfway through a change.
+//
+// readers share latches all the way down. so do writers, who only latch the leaf
+// exclusively, since most inserts and deletes change nothing else. when the leaf can not
+// take the change, because it is full or has no key to spare, the writer lets go and
+// starts over, latching exclusively from the lowest ancestor it saw that could absorb a
+// split or merge below it. only when there is none does it latch the root exclusively.
+//
+// below that point insert and delete fix nodes on the way down, splitting full children
+// and filling minimal ones, so a writer never has to go back up. once the child it moves
+// to is fixed, the parent can not change again and is released right away, so writers
+// only hold two levels at a time, plus siblings while borrowing or merging.
+//
+// the nodes are not BTree's, so the latch costs nothing in trees that never use it
+type Concurrent[K any, V any] struct {
+	// mu guards root and height. it is only held exclusively by a writer that may split
+	// or shrink the root, until the root node is safe
+	mu      sync.RWMutex
+	root    *cnode[K, V]
+	degree  int
+	height  int
+	size    atomic.Int64
+	compare func(K, K) int
+}
+
+// cnode is node with a latch, guarding every other field
+type cnode[K any, V any] struct {
+	n        int
+	leaf     bool
+	keys     []container[K, V]
+	children []*cnode[K, V]
+	latch    sync.RWMutex
+}
+
+func newCNode[K any, V any](t int) *cnode[K, V] {
+	return &cnode[K, V]{
+		n:        0,
+		leaf:     true,
+		keys:     make([]container[K, V], 2*t-1),
+		children: make([]*cnode[K, V], 2*t),
+	}
+}
+
+// lock latches x exclusively when excl is set, otherwise shared
+func (x *cnode[K, V]) lock(excl bool) {
+	if excl {
+		x.latch.Lock()
+	} else {
+		x.latch.RLock()
+	}
+}
+
+func (x *cnode[K, V]) unlock(excl bool) {
+	if excl {
+		x.latch.Unlock()
+	} else {
+		x.latch.RUnlock()
+	}
+}
+
+func NewConcurrent[K any, V any](degree int, compare func(K, K) int) *Concurrent[K, V] {
+	return &Concurrent[K, V]{
+		root:    newCNode[K, V](degree),
+		degree:  degree,
+		height:  0,
+		compare: compare,
+	}
+}
+
+func (c *Concurrent[K, V]) Search(k K) (val V, found bool) {
+	c.mu.RLock()
+	x := c.root
+	x.latch.RLock()
+	c.mu.RUnlock()
+	for {
+		i, found := c.bsearch(x, k)
+		if found {
+			val = x.keys[i].val
+			x.latch.RUnlock()
+			return val, true
+		}
+		if x.leaf {
+			x.latch.RUnlock()
+			return val, false
+		}
+		y := x.children[i]
+		y.latch.RLock()
+		x.latch.RUnlock()
+		x = y
+	}
+}
+
+// a writer's first pass latches exclusively only from this depth down, which is
+// never reached, so just the leaf is
+const optimistic = math.MaxInt
+
+// enter latches the root node for a writer that latches exclusively from depth d down.
+// below 0 the tree's root latch is taken exclusively as well and left for the caller to
+// release, otherwise it is shared and released once the root node is latched
+func (c *Concurrent[K, V]) enter(d int) (x *cnode[K, V], excl bool) {
+	if d < 0 {
+		c.mu.Lock()
+		c.root.latch.Lock()
+		return c.root, true
+	}
+	c.mu.RLock()
+	x = c.root
+	excl = d == 0 || x.leaf
+	x.lock(excl)
+	c.mu.RUnlock()
+	return x, excl
+}
+
+func (c *Concurrent[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	for d := optimistic; ; {
+		var ok bool
+		if old, replaced, ok, d = c.insert(k, v, d); ok {
+			return old, replaced
+		}
+	}
+}
+
+// insert is one pass down the tree, latching exclusively from depth d down. when the
+// first node latched exclusively would have to split, which changes its parent, the
+// pass gives up and returns the depth to start over from: the lowest shared ancestor
+// that was not full, or -1 for the root latch
+func (c *Concurrent[K, V]) insert(k K, v V, d int) (old V, replaced, ok bool, retry int) {
+	full := 2*c.degree - 1
+	x, excl := c.enter(d)
+	if d < 0 {
+		if x.n == full {
+			s := c.splitRoot()
+			s.latch.Lock()
+			x.latch.Unlock()
+			x = s
+		}
+		// the root is not full, so it will not split under this insert
+		c.mu.Unlock()
+	}
+	safe := -1
+	for depth := 0; ; depth++ {
+		i, found := c.bsearch(x, k)
+		if found {
+			if !excl {
+				x.latch.RUnlock()
+				return old, false, false, depth
+			}
+			old = x.keys[i].val
+			x.keys[i].val = v
+			x.latch.Unlock()
+			return old, true, true, 0
+		}
+		if x.leaf {
+			// a leaf below the first exclusive node was split on the way if it was full
+			if x.n == full {
+				x.latch.Unlock()
+				return old, false, false, safe
+			}
+			copy(x.keys[i+1:x.n+1], x.keys[i:x.n])
+			x.keys[i] = container[K, V]{key: k, val: v}
+			x.n++
+			c.size.Add(1)
+			x.latch.Unlock()
+			return old, false, true, 0
+		}
+		if !excl && x.n < full {
+			safe = depth
+		}
+		y := x.children[i]
+		yExcl := excl || depth+1 >= d || y.leaf
+		y.lock(yExcl)
+		if excl && y.n == full {
+			if x.n == full {
+				y.latch.Unlock()
+				x.latch.Unlock()
+				return old, false, false, safe
+			}
+			c.splitChild(x, i)
+			// the new right half is only reachable through x, so latching it can not block
+			cmp := c.compare(k, x.keys[i].key)
+			if cmp == 0 {
+				old = x.keys[i].val
+				x.keys[i].val = v
+				y.latch.Unlock()
+				x.latch.Unlock()
+				return old, true, true, 0
+			} else if cmp > 0 {
+				z := x.children[i+1]
+				z.latch.Lock()
+				y.latch.Unlock()
+				y = z
+			}
+		}
+		x.unlock(excl)
+		x, excl = y, yExcl
+	}
+}
+
+// Delete follows BTree.Delete, latching the siblings of a child while it borrows or merges
+func (c *Concurrent[K, V]) Delete(k K) (old V, found bool) {
+	for d := optimistic; ; {
+		var ok bool
+		if old, found, ok, d = c.delete(k, d); ok {
+			return old, found
+		}
+	}
+}
+
+// delete is one pass down the tree like insert. it gives up when the first node latched
+// exclusively would have to lose a key it does not have to spare, or when k is found in
+// a node latched shared
+func (c *Concurrent[K, V]) delete(k K, d int) (old V, found, ok bool, retry int) {
+	t := c.degree
+	x, excl := c.enter(d)
+	atRoot := d < 0
+	// release lets go of x, and of the root latch once the walk has left the root
+	release := func() {
+		x.unlock(excl)
+		if atRoot {
+			c.mu.Unlock()
+			atRoot = false
+		}
+	}
+
+	safe := -1
+	for depth := 0; ; depth++ {
+		i, found := c.bsearch(x, k)
+		if found && !excl {
+			release()
+			return old, false, false, depth
+		}
+		spare := c.spare(x, depth, atRoot)
+		if x.leaf {
+			if found {
+				if !spare {
+					release()
+					return old, false, false, safe
+				}
+				old = x.keys[i].val
+				copy(x.keys[i:], x.keys[i+1:x.n])
+				x.n--
+				x.keys[x.n] = container[K, V]{}
+				c.size.Add(-1)
+			}
+			release()
+			return old, found, true, 0
+		}
+		if !excl {
+			if spare {
+				safe = depth
+			}
+			y := x.children[i]
+			yExcl := depth+1 >= d || y.leaf
+			y.lock(yExcl)
+			release()
+			x, excl = y, yExcl
+			continue
+		}
+		var y *cnode[K, V]
+		if found {
+			old = x.keys[i].val
+			l := x.children[i]
+			l.latch.Lock()
+			if l.n >= t {
+				x.keys[i] = c.popEdge(l, true)
+				c.size.Add(-1)
+				release()
+				return old, true, true, 0
+			}
+			r := x.children[i+1]
+			r.latch.Lock()
+			if r.n >= t {
+				l.latch.Unlock()
+				x.keys[i] = c.popEdge(r, false)
+				c.size.Add(-1)
+				release()
+				return old, true, true, 0
+			}
+			if !spare {
+				r.latch.Unlock()
+				l.latch.Unlock()
+				release()
+				return old, false, false, safe
+			}
+			// k moves down into the merge and is found there next
+			c.merge(x, i)
+			r.latch.Unlock()
+			y = l
+		} else if i = c.fill(x, i, spare); i < 0 {
+			release()
+			return old, false, false, safe
+		} else {
+			y = x.children[i]
+		}
+		if atRoot && x.n == 0 {
+			c.root = y
+			c.height--
+		}
+		release()
+		x = y
+	}
+}
+
+// spare reports whether x, at depth, can lose a key. below the root that takes more than
+// t-1 keys. a leaf root can lose them all, an internal root all but one, or the last one
+// too when the root latch is held, since its only child then takes its place
+func (c *Concurrent[K, V]) spare(x *cnode[K, V], depth int, atRoot bool) bool {
+	switch {
+	case depth > 0:
+		return x.n >= c.degree
+	case x.leaf:
+		return true
+	default:
+		return x.n > 1 || atRoot
+	}
+}
+
+// fill makes sure child i of x has at least t keys, borrowing from or merging with a
+// sibling. x is latched for writing. it returns the index of the child that now covers
+// the same keys, which is left latched for writing, while any sibling is released.
+// merging takes a key from x, so when x has none to spare fill releases the children
+// and returns -1 instead
+func (c *Concurrent[K, V]) fill(x *cnode[K, V], i int, spare bool) int {
+	t := c.degree
+	y := x.children[i]
+	y.latch.Lock()
+	if y.n >= t {
+		return i
+	}
+	// only a goroutine holding x can latch its children, so siblings are free of deadlock
+	var l *cnode[K, V]
+	if i > 0 {
+		l = x.children[i-1]
+		l.latch.Lock()
+		if l.n >= t {
+			c.borrowLeft(x, i)
+			l.latch.Unlock()
+			return i
+		}
+	}
+	if i < x.n {
+		r := x.children[i+1]
+		r.latch.Lock()
+		borrow := r.n >= t
+		if borrow {
+			c.borrowRight(x, i)
+		} else if spare {
+			c.merge(x, i)
+		}
+		r.latch.Unlock()
+		if l != nil {
+			l.latch.Unlock()
+		}
+		if !borrow && !spare {
+			y.latch.Unlock()
+			return -1
+		}
+		return i
+	}
+	if !spare {
+		l.latch.Unlock()
+		y.latch.Unlock()
+		return -1
+	}
+	c.merge(x, i-1)
+	y.latch.Unlock()
+	return i - 1
+}
+
+// popEdge removes and returns the largest entry under y when max is set, otherwise the
+// smallest. y is latched for writing and has at least t keys, and is released on return
+func (c *Concurrent[K, V]) popEdge(y *cnode[K, V], max bool) container[K, V] {
+	for !y.leaf {
+		i := 0
+		if max {
+			i = y.n
+		}
+		z := y.children[c.fill(y, i, true)]
+		y.latch.Unlock()
+		y = z
+	}
+	var e container[K, V]
+	if max {
+		e = y.keys[y.n-1]
+	} else {
+		e = y.keys[0]
+		copy(y.keys, y.keys[1:y.n])
+	}
+	y.n--
+	y.keys[y.n] = container[K, V]{}
+	y.latch.Unlock()
+	return e
+}
+
+// splitChild, splitRoot, merge, borrowLeft and borrowRight are BTree's, on cnodes.
+// the caller holds every node involved for writing
+
+func (c *Concurrent[K, V]) splitChild(x *cnode[K, V], i int) {
+	t := c.degree
+	y := x.children[i]
+	z := newCNode[K, V](t)
+	z.leaf = y.leaf
+	z.n = t - 1
+	copy(z.keys, y.keys[t:2*t-1])
+	if !y.leaf {
+		copy(z.children, y.children[t:2*t])
+	}
+	y.n = t - 1
+	copy(x.children[i+2:x.n+2], x.children[i+1:x.n+1])
+	x.children[i+1] = z
+	copy(x.keys[i+1:x.n+1], x.keys[i:x.n])
+	x.keys[i] = y.keys[t-1]
+	x.n++
+	// as in BTree.splitChild, clear what moved out of y so it does not alias live values
+	clear(y.keys[t-1 : 2*t-1])
+	clear(y.children[t : 2*t])
+}
+
+func (c *Concurrent[K, V]) splitRoot() *cnode[K, V] {
+	s := newCNode[K, V](c.degree)
+	s.leaf = false
+	s.children[0] = c.root
+	c.root = s
+	c.splitChild(s, 0)
+	c.height++
+	return s
+}
+
+func (c *Concurrent[K, V]) merge(x *cnode[K, V], i int) {
+	y, z := x.children[i], x.children[i+1]
+	y.keys[y.n] = x.keys[i]
+	copy(y.keys[y.n+1:], z.keys[:z.n])
+	if !y.leaf {
+		copy(y.children[y.n+1:], z.children[:z.n+1])
+	}
+	y.n += 1 + z.n
+	copy(x.keys[i:], x.keys[i+1:x.n])
+	copy(x.children[i+1:], x.children[i+2:x.n+1])
+	x.keys[x.n-1] = container[K, V]{}
+	x.children[x.n] = nil
+	x.n--
+}
+
+func (c *Concurrent[K, V]) borrowLeft(x *cnode[K, V], i int) {
+	y, s := x.children[i], x.children[i-1]
+	copy(y.keys[1:y.n+1], y.keys[:y.n])
+	y.keys[0] = x.keys[i-1]
+	if !y.leaf {
+		copy(y.children[1:y.n+2], y.children[:y.n+1])
+		y.children[0] = s.children[s.n]
+		s.children[s.n] = nil
+	}
+	y.n++
+	x.keys[i-1] = s.keys[s.n-1]
+	s.keys[s.n-1] = container[K, V]{}
+	s.n--
+}
+
+func (c *Concurrent[K, V]) borrowRight(x *cnode[K, V], i int) {
+	y, s := x.children[i], x.children[i+1]
+	y.keys[y.n] = x.keys[i]
+	if !y.leaf {
+		y.children[y.n+1] = s.children[0]
+		copy(s.children, s.children[1:s.n+1])
+		s.children[s.n] = nil
+	}
+	y.n++
+	x.keys[i] = s.keys[0]
+	copy(s.keys, s.keys[1:s.n])
+	s.keys[s.n-1] = container[K, V]{}
+	s.n--
+}
+
+// bsearch is BTree.bsearch on a cnode
+func (c *Concurrent[K, V]) bsearch(x *cnode[K, V], k K) (int, bool) {
+	low, high := 0, x.n-1
+	for low <= high {
+		mid := low + (high-low)/2
+		cmp := c.compare(x.keys[mid].key, k)
+		if cmp == 0 {
+			return mid, true
+		} else if cmp < 0 {
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, false
+}
+
+// Size is O(1)
+func (c *Concurrent[K, V]) Size() int {
+	return int(c.size.Load())
+}
+
+func (c *Concurrent[K, V]) Height() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.height
+}
+
+func (c *Concurrent[K, V]) Degree() int {
+	return c.degree
+}
+
+// Validate checks the tree like BTree.Validate. it does not latch the nodes, so it
+// must not run alongside other calls
+func (c *Concurrent[K, V]) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b := BTree[K, V]{
+		degree:  c.degree,
+		height:  c.height,
+		size:    c.Size(),
+		compare: c.compare,
+	}
+	if c.root != nil {
+		b.root = plain(c.root)
+	}
+	return b.Validate()
+}
+
+// plain copies the shape of the subtree x into BTree nodes, sharing the keys, so
+// Validate can reuse BTree's checks
+func plain[K any, V any](x *cnode[K, V]) *node[K, V] {
+	y := &node[K, V]{n: x.n, leaf: x.leaf, keys: x.keys}
+	if !x.leaf {
+		y.children = make([]*node[K, V], len(x.children))
+		for i := 0; i <= x.n; i++ {
+			if x.children[i] != nil {
+				y.children[i] = plain(x.children[i])
+			}
+		}
+	}
+	return y
+}
@@ -0,0 +1,86 @@
+package btree_mem
+
+import "cmp"
+
+// Ordered is a BTree for keys with a built in order. Search and Insert compare keys
+// directly instead of through the compare func, which the compiler can inline. every
+// other method is the BTree's own, using cmp.Compare, which gives the same order.
+// NaN keys are not supported, since NaN is neither less than nor equal to itself
+type Ordered[K cmp.Ordered, V any] struct {
+	*BTree[K, V]
+}
+
+func NewOrdered[K cmp.Ordered, V any](degree int) *Ordered[K, V] {
+	return &Ordered[K, V]{New[K, V](degree, cmp.Compare[K])}
+}
+
+// bsearch is BTree.bsearch with one comparison per probe and an equality check at the end
+func (o *Ordered[K, V]) bsearch(x *node[K, V], k K) (int, bool) {
+	low, high := 0, x.n
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+		if x.keys[mid].key < k {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, low < x.n && x.keys[low].key == k
+}
+
+func (o *Ordered[K, V]) Search(k K) (val V, found bool) {
+	x := o.root
+	for {
+		i, found := o.bsearch(x, k)
+		if found {
+			return x.keys[i].val, true
+		}
+		if x.leaf {
+			return val, false
+		}
+		x = x.children[i]
+	}
+}
+
+// Insert is BTree.Insert as a loop, splitting full children on the way down
+func (o *Ordered[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	b := o.BTree
+	b.root = b.mutable(b.root)
+	x := b.root
+	if x.n == 2*b.degree-1 {
+		x = b.splitRoot()
+	}
+	for {
+		i, found := o.bsearch(x, k)
+		if found {
+			old = x.keys[i].val
+			x.keys[i].val = v
+			return old, true
+		}
+		if x.leaf {
+			copy(x.keys[i+1:x.n+1], x.keys[i:x.n])
+			x.keys[i] = container[K, V]{key: k, val: v}
+			x.n++
+			b.size++
+			return old, false
+		}
+		if x.children[i].n == 2*b.degree-1 {
+			b.mutableChild(x, i)
+			b.splitChild(x, i)
+			// the median moved up into x
+			if m := x.keys[i].key; m == k {
+				old = x.keys[i].val
+				x.keys[i].val = v
+				return old, true
+			} else if m < k {
+				i++
+			}
+		}
+		x = b.mutableChild(x, i)
+	}
+}
+
+// Clone is BTree.Clone, keeping the fast path
+func (o *Ordered[K, V]) Clone() *Ordered[K, V] {
+	return &Ordered[K, V]{o.BTree.Clone()}
+}
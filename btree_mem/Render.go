@@ -0,0 +1,105 @@
+package btree_mem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the tree in Graphviz DOT format. each node is a record with
+// its keys between ports for the children, so edges leave from between the keys
+//
+//	dot -Tsvg tree.dot > tree.svg
+func (b *BTree[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph btree {")
+	fmt.Fprintln(bw, "\tnode [shape=record];")
+	id := 0
+	b.writeDOT(bw, b.root, &id)
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDOT writes x and its subtree, numbering nodes in pre-order, and returns the id of x
+func (b *BTree[K, V]) writeDOT(w io.Writer, x *node[K, V], id *int) int {
+	me := *id
+	*id++
+	var fields []string
+	for i := 0; i < x.n; i++ {
+		if !x.leaf {
+			fields = append(fields, fmt.Sprintf("<c%d>", i))
+		}
+		fields = append(fields, recordEscape(fmt.Sprint(x.keys[i].key)))
+	}
+	if !x.leaf {
+		fields = append(fields, fmt.Sprintf("<c%d>", x.n))
+	}
+	fmt.Fprintf(w, "\tn%d [label=\"%s\"];\n", me, strings.Join(fields, "|"))
+	if !x.leaf {
+		for i := 0; i <= x.n; i++ {
+			c := b.writeDOT(w, x.children[i], id)
+			fmt.Fprintf(w, "\tn%d:c%d -> n%d;\n", me, i, c)
+		}
+	}
+	return me
+}
+
+// recordEscape escapes the characters that have a meaning inside a DOT record label
+func recordEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, `|`, `\|`, `{`, `\{`, `}`, `\}`, `<`, `\<`, `>`, `\>`, "\n", `\n`,
+	).Replace(s)
+}
+
+// WriteASCII writes the tree sideways, the root on the left and larger keys above smaller
+// ones, so reading down gives the keys in descending order. a leaf is one line, an internal
+// node puts each key on its own line with each child one level deeper, in the gap between
+// the two keys that separate it. a bar joins the keys of one node across those gaps
+//
+//	        [11 12]
+//	    10
+//	    |   [9]
+//	    8
+//	    |   [7]
+//	    6
+//	        [5]
+//	4
+//	        [3]
+//	    2
+//	        [1]
+func (b *BTree[K, V]) WriteASCII(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if b.root.n > 0 {
+		b.writeASCII(bw, b.root, "")
+	}
+	return bw.Flush()
+}
+
+// writeASCII writes the subtree at x with its keys after indent. every line of a child
+// starts with indent and then the bar, which is blank for the first and last child since
+// it only runs between keys
+func (b *BTree[K, V]) writeASCII(w io.Writer, x *node[K, V], indent string) {
+	if x.leaf {
+		fmt.Fprintln(w, indent+b.label(x))
+		return
+	}
+	for i := x.n; i >= 0; i-- {
+		bar := "|   "
+		if i == x.n || i == 0 {
+			bar = "    "
+		}
+		b.writeASCII(w, x.children[i], indent+bar)
+		if i > 0 {
+			fmt.Fprintln(w, indent+fmt.Sprint(x.keys[i-1].key))
+		}
+	}
+}
+
+func (b *BTree[K, V]) label(x *node[K, V]) string {
+	keys := make([]string, x.n)
+	for i := 0; i < x.n; i++ {
+		keys[i] = fmt.Sprint(x.keys[i].key)
+	}
+	return "[" + strings.Join(keys, " ") + "]"
+}
@@ -0,0 +1,382 @@
+package btree_mem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// a snapshot is the node structure itself, so loading it allocates each node once
+// instead of replaying every insert. all integers are uvarints unless noted
+//
+//	magic     "BTMS"
+//	version   1 byte
+//	degree, height, size
+//	nodes     pre-order from the root. each is a leaf flag byte, the key count n,
+//	          then n keys and values, each a length followed by its codec's bytes
+//	checksum  CRC-32C of everything above, 4 bytes big endian
+const (
+	snapshotMagic   = "BTMS"
+	snapshotVersion = 1
+	// bounds on header fields. nothing is allocated from them before the checksum,
+	// these keep a forged header from asking for absurd trees after it
+	maxSnapshotDegree = 1 << 20
+	maxSnapshotHeight = 64
+	maxSnapshotItem   = 1 << 26
+)
+
+// ErrBadSnapshot is returned by ReadFrom when the input is not a snapshot it can load
+var ErrBadSnapshot = errors.New("btree_mem: bad snapshot")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SetCodecs sets how WriteTo and ReadFrom encode keys and values
+func (b *BTree[K, V]) SetCodecs(keys Codec[K], vals Codec[V]) {
+	b.keyCodec = keys
+	b.valCodec = vals
+}
+
+// snapshotWriter keeps the first error, so writes can be chained and checked once
+type snapshotWriter struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	n   int64
+	buf []byte
+	err error
+}
+
+func (s *snapshotWriter) write(p []byte) {
+	if s.err != nil {
+		return
+	}
+	var n int
+	n, s.err = s.w.Write(p)
+	s.n += int64(n)
+	s.crc.Write(p[:n])
+}
+
+func (s *snapshotWriter) uvarint(x uint64) {
+	s.write(binary.AppendUvarint(s.buf[:0], x))
+}
+
+// WriteTo writes a snapshot of the tree that ReadFrom can load. SetCodecs must have
+// been called first
+func (b *BTree[K, V]) WriteTo(w io.Writer) (int64, error) {
+	if b.keyCodec == nil || b.valCodec == nil {
+		return 0, errors.New("btree_mem: SetCodecs must be called before WriteTo")
+	}
+	s := &snapshotWriter{w: bufio.NewWriter(w), crc: crc32.New(castagnoli), buf: make([]byte, 0, binary.MaxVarintLen64)}
+	s.write([]byte(snapshotMagic))
+	s.write([]byte{snapshotVersion})
+	s.uvarint(uint64(b.degree))
+	s.uvarint(uint64(b.height))
+	s.uvarint(uint64(b.size))
+	b.writeNode(s, b.root)
+	// the checksum itself is not part of what it covers
+	if s.err == nil {
+		var n int
+		n, s.err = s.w.Write(s.crc.Sum(nil))
+		s.n += int64(n)
+	}
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.n, s.err
+}
+
+func (b *BTree[K, V]) writeNode(s *snapshotWriter, x *node[K, V]) {
+	leaf := byte(0)
+	if x.leaf {
+		leaf = 1
+	}
+	s.write([]byte{leaf})
+	s.uvarint(uint64(x.n))
+	var item []byte
+	for i := 0; i < x.n && s.err == nil; i++ {
+		item = b.keyCodec.Append(item[:0], x.keys[i].key)
+		s.uvarint(uint64(len(item)))
+		s.write(item)
+		item = b.valCodec.Append(item[:0], x.keys[i].val)
+		s.uvarint(uint64(len(item)))
+		s.write(item)
+	}
+	if !x.leaf {
+		for i := 0; i <= x.n && s.err == nil; i++ {
+			b.writeNode(s, x.children[i])
+		}
+	}
+}
+
+// ReadFrom reads in two passes, so a damaged or hostile header can not make it allocate
+// anything in proportion to the degree or size it claims. the first pass follows the
+// structure, checking key counts and leaf depths, and keeps the raw bytes until the
+// checksum is verified. the second decodes those bytes into nodes sized by their key
+// count. such nodes are owned by no tree, so mutable copies each one into a full size
+// node the first time it is written
+type snapshotReader struct {
+	r *bufio.Reader
+	n int64
+	// in the first pass, every byte read is also written here, to the checksum and the kept copy
+	w io.Writer
+}
+
+func (s *snapshotReader) ReadByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err == nil {
+		s.n++
+		if s.w != nil {
+			s.w.Write([]byte{c})
+		}
+	}
+	return c, err
+}
+
+func (s *snapshotReader) read(p []byte) error {
+	n, err := io.ReadFull(s.r, p)
+	s.n += int64(n)
+	if s.w != nil {
+		s.w.Write(p[:n])
+	}
+	return err
+}
+
+// uvarint reads a uvarint no larger than limit
+func (s *snapshotReader) uvarint(limit uint64, what string) (uint64, error) {
+	x, err := binary.ReadUvarint(s)
+	if err != nil {
+		return 0, err
+	}
+	if x > limit {
+		return 0, fmt.Errorf("%w: %s %d is larger than %d", ErrBadSnapshot, what, x, limit)
+	}
+	return x, nil
+}
+
+// ReadFrom replaces the contents of the tree with a snapshot written by WriteTo,
+// including its degree. the compare func and codecs stay as they are, and must order
+// and decode keys as the tree that wrote it did. the tree is left unchanged on error.
+// r is read through a buffer, so it may be read past the end of the snapshot
+func (b *BTree[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	if b.keyCodec == nil || b.valCodec == nil {
+		return 0, errors.New("btree_mem: SetCodecs must be called before ReadFrom")
+	}
+	s := &snapshotReader{r: bufio.NewReader(r)}
+	n, err := b.readSnapshot(s)
+	// running out of input anywhere means the snapshot was cut short
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = fmt.Errorf("%w: %w", ErrBadSnapshot, io.ErrUnexpectedEOF)
+	}
+	return n, err
+}
+
+// snapshotHeader is what comes before the nodes
+type snapshotHeader struct {
+	degree, height, size uint64
+}
+
+func (s *snapshotReader) header() (h snapshotHeader, err error) {
+	head := make([]byte, len(snapshotMagic)+1)
+	if err := s.read(head); err != nil {
+		return h, err
+	}
+	if string(head[:len(snapshotMagic)]) != snapshotMagic {
+		return h, fmt.Errorf("%w: not a snapshot", ErrBadSnapshot)
+	}
+	if v := head[len(snapshotMagic)]; v != snapshotVersion {
+		return h, fmt.Errorf("%w: version %d is not supported", ErrBadSnapshot, v)
+	}
+	if h.degree, err = s.uvarint(maxSnapshotDegree, "degree"); err != nil {
+		return h, err
+	}
+	if h.degree < 2 {
+		return h, fmt.Errorf("%w: degree %d is less than 2", ErrBadSnapshot, h.degree)
+	}
+	if h.height, err = s.uvarint(maxSnapshotHeight, "height"); err != nil {
+		return h, err
+	}
+	if h.size, err = s.uvarint(math.MaxInt64, "size"); err != nil {
+		return h, err
+	}
+	if lo, hi := keyBounds(h.degree, h.height); h.size < lo || h.size > hi {
+		return h, fmt.Errorf("%w: %d keys do not fit a tree of degree %d and height %d", ErrBadSnapshot, h.size, h.degree, h.height)
+	}
+	return h, nil
+}
+
+// keyBounds is the fewest and the most keys a tree of degree t and height h can hold,
+// saturating at math.MaxUint64
+func keyBounds(t, h uint64) (lo, hi uint64) {
+	pow := func(x, e uint64) uint64 {
+		p := uint64(1)
+		for ; e > 0; e-- {
+			over, low := bits.Mul64(p, x)
+			if over != 0 {
+				return math.MaxUint64
+			}
+			p = low
+		}
+		return p
+	}
+	if h == 0 {
+		return 0, 2*t - 1
+	}
+	// a root with one key above two minimal subtrees, or every node full
+	lo = pow(t, h)
+	if lo < math.MaxUint64/2 {
+		lo = 2*lo - 1
+	}
+	hi = pow(2*t, h+1)
+	if hi < math.MaxUint64 {
+		hi--
+	}
+	return lo, hi
+}
+
+func (b *BTree[K, V]) readSnapshot(s *snapshotReader) (int64, error) {
+	crc := crc32.New(castagnoli)
+	var raw bytes.Buffer
+	s.w = io.MultiWriter(crc, &raw)
+	h, err := s.header()
+	if err != nil {
+		return s.n, err
+	}
+	body := raw.Len()
+	count, err := s.skipNode(h, 0)
+	if err != nil {
+		return s.n, err
+	}
+	want := crc.Sum32()
+	s.w = nil
+	sum := make([]byte, 4)
+	if err := s.read(sum); err != nil {
+		return s.n, err
+	}
+	if binary.BigEndian.Uint32(sum) != want {
+		return s.n, fmt.Errorf("%w: checksum does not match", ErrBadSnapshot)
+	}
+	if count != h.size {
+		return s.n, fmt.Errorf("%w: size is %d but the snapshot has %d keys", ErrBadSnapshot, h.size, count)
+	}
+
+	// build a separate tree, so a bad snapshot leaves b as it was
+	t := &BTree[K, V]{
+		degree:   int(h.degree),
+		height:   int(h.height),
+		size:     int(h.size),
+		compare:  b.compare,
+		owner:    b.owner,
+		keyCodec: b.keyCodec,
+		valCodec: b.valCodec,
+	}
+	nodes := &snapshotReader{r: bufio.NewReader(bytes.NewReader(raw.Bytes()[body:]))}
+	if t.root, err = t.readNode(nodes); err != nil {
+		return s.n, err
+	}
+	// the checksum only proves the bytes are intact. Validate catches a snapshot
+	// that was written with a different order or key codec
+	if err := t.Validate(); err != nil {
+		return s.n, fmt.Errorf("%w: %w", ErrBadSnapshot, err)
+	}
+	b.root, b.degree, b.height, b.size = t.root, t.degree, t.height, t.size
+	return s.n, nil
+}
+
+// skipNode checks the node at depth and everything below it in the first pass,
+// returning how many keys they hold
+func (s *snapshotReader) skipNode(h snapshotHeader, depth uint64) (uint64, error) {
+	leaf, err := s.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if leaf > 1 || (leaf == 1) != (depth == h.height) {
+		return 0, fmt.Errorf("%w: node at depth %d has leaf flag %d with height %d", ErrBadSnapshot, depth, leaf, h.height)
+	}
+	n, err := s.uvarint(2*h.degree-1, "key count")
+	if err != nil {
+		return 0, err
+	}
+	if depth > 0 && n < h.degree-1 || depth == 0 && leaf == 0 && n == 0 {
+		return 0, fmt.Errorf("%w: node at depth %d has %d keys with degree %d", ErrBadSnapshot, depth, n, h.degree)
+	}
+	for i := uint64(0); i < 2*n; i++ {
+		l, err := s.uvarint(maxSnapshotItem, "item length")
+		if err != nil {
+			return 0, err
+		}
+		// copied as it arrives, so the kept bytes only grow as far as the input really goes
+		c, err := io.CopyN(s.w, s.r, int64(l))
+		s.n += c
+		if err != nil {
+			return 0, err
+		}
+	}
+	count := n
+	if leaf == 0 {
+		for i := uint64(0); i <= n; i++ {
+			c, err := s.skipNode(h, depth+1)
+			if err != nil {
+				return 0, err
+			}
+			count += c
+		}
+	}
+	return count, nil
+}
+
+// readNode decodes a node and everything below it in the second pass, from bytes the
+// first pass already checked
+func (b *BTree[K, V]) readNode(s *snapshotReader) (*node[K, V], error) {
+	leaf, err := s.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	n, err := binary.ReadUvarint(s)
+	if err != nil {
+		return nil, err
+	}
+	x := &node[K, V]{n: int(n), leaf: leaf == 1, keys: make([]container[K, V], n)}
+	var item []byte
+	for i := 0; i < x.n; i++ {
+		if item, err = s.item(item); err != nil {
+			return nil, err
+		}
+		if x.keys[i].key, err = b.keyCodec.Decode(item); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadSnapshot, err)
+		}
+		if item, err = s.item(item); err != nil {
+			return nil, err
+		}
+		if x.keys[i].val, err = b.valCodec.Decode(item); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadSnapshot, err)
+		}
+	}
+	if !x.leaf {
+		x.children = make([]*node[K, V], n+1)
+		for i := 0; i <= x.n; i++ {
+			if x.children[i], err = b.readNode(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return x, nil
+}
+
+// item reads one length prefixed key or value, reusing buf
+func (s *snapshotReader) item(buf []byte) ([]byte, error) {
+	l, err := binary.ReadUvarint(s)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(cap(buf)) < l {
+		buf = make([]byte, l)
+	}
+	buf = buf[:l]
+	return buf, s.read(buf)
+}
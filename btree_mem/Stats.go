@@ -0,0 +1,29 @@
+package btree_mem
+
+import (
+	"unsafe"
+
+	"github.com/a-tk/go-datastructures/internal/btstats"
+)
+
+// Stats describes the shape of a tree, see btstats.Stats
+type Stats = btstats.Stats
+
+// Stats walks every node, so it is O(n / t)
+func (b *BTree[K, V]) Stats() Stats {
+	return btstats.Collect(b.degree, b.height, b.size, b.root, b.statNode)
+}
+
+func (b *BTree[K, V]) statNode(x *node[K, V]) btstats.Node[*node[K, V]] {
+	var c container[K, V]
+	v := btstats.Node[*node[K, V]]{
+		N:     x.n,
+		Leaf:  x.leaf,
+		Slots: cap(x.keys),
+		Bytes: int(unsafe.Sizeof(*x)) + cap(x.keys)*int(unsafe.Sizeof(c)) + cap(x.children)*int(unsafe.Sizeof(x)),
+	}
+	if !x.leaf {
+		v.Children = x.children[:x.n+1]
+	}
+	return v
+}
@@ -0,0 +1,168 @@
+package btree_mem
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+func TestBPlusTree_InsertDelete(t *testing.T) {
+	for _, degree := range []int{2, 3, 10} {
+		r := rand.New(rand.NewSource(123))
+		b := NewBPlus[int, int](degree, intcmp)
+		m := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(2000)
+			old, replaced := b.Insert(k, i)
+			prev, ok := m[k]
+			if replaced != ok || old != prev {
+				t.Errorf("degree=%d: Insert(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, replaced, prev, ok)
+			}
+			m[k] = i
+		}
+		if err := b.Validate(); err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		for i := 0; i < 4000; i++ {
+			k := r.Intn(2000)
+			old, found := b.Delete(k)
+			prev, ok := m[k]
+			if found != ok || old != prev {
+				t.Errorf("degree=%d: Delete(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, found, prev, ok)
+			}
+			delete(m, k)
+			if i%250 == 0 {
+				if err := b.Validate(); err != nil {
+					t.Fatalf("degree=%d: after %d deletes, %v", degree, i, err)
+				}
+			}
+		}
+		if b.Size() != len(m) {
+			t.Errorf("degree=%d: size is %d, expected %d", degree, b.Size(), len(m))
+		}
+		for k := 0; k < 2000; k++ {
+			v, found := b.Search(k)
+			if prev, ok := m[k]; found != ok || v != prev {
+				t.Errorf("degree=%d: Search(%d) returned (%d, %v), expected (%d, %v)", degree, k, v, found, prev, ok)
+			}
+		}
+		for k := range m {
+			b.Delete(k)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("degree=%d: emptied tree, %v", degree, err)
+		}
+		if b.Size() != 0 || b.Height() != 0 {
+			t.Errorf("degree=%d: emptied tree has size %d and height %d", degree, b.Size(), b.Height())
+		}
+	}
+}
+
+func TestBPlusTree_Range(t *testing.T) {
+	b := NewBPlus[int, int](3, intcmp)
+	r := rand.New(rand.NewSource(321))
+	var keys []int
+	for _, k := range r.Perm(500) {
+		b.Insert(k*2, -k*2)
+		keys = append(keys, k*2)
+	}
+	slices.Sort(keys)
+
+	var got []int
+	for k, v := range b.All() {
+		if v != -k {
+			t.Errorf("All yielded (%d, %d)", k, v)
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, keys) {
+		t.Errorf("All yielded %v", got)
+	}
+
+	for i := 0; i < 200; i++ {
+		lo, hi := r.Intn(1020)-10, r.Intn(1020)-10
+		var expected []int
+		for _, k := range keys {
+			if k >= lo && k < hi {
+				expected = append(expected, k)
+			}
+		}
+		got = got[:0]
+		for k := range b.Range(lo, hi) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("Range(%d, %d) yielded %v, expected %v", lo, hi, got, expected)
+		}
+	}
+
+	got = got[:0]
+	for k := range b.Range(101, 1000) {
+		got = append(got, k)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if !slices.Equal(got, []int{102, 104, 106}) {
+		t.Errorf("Range with break yielded %v", got)
+	}
+}
+
+// the scan benchmarks build both trees once, then time walking them
+
+const scanSize = 1_000_000
+
+func scanTrees(degree int) (*BTree[int, int], *BPlusTree[int, int]) {
+	bt := New[int, int](degree, intcmp)
+	bp := NewBPlus[int, int](degree, intcmp)
+	r := rand.New(rand.NewSource(123))
+	for _, k := range r.Perm(scanSize) {
+		bt.Insert(k, k)
+		bp.Insert(k, k)
+	}
+	return bt, bp
+}
+
+func BenchmarkScan(b *testing.B) {
+	bt, bp := scanTrees(32)
+	b.Run("BTree/All", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			for _, v := range bt.All() {
+				sum += v
+			}
+		}
+	})
+	b.Run("BPlusTree/All", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			for _, v := range bp.All() {
+				sum += v
+			}
+		}
+	})
+	// many short range scans, as a query per time window would do
+	r := rand.New(rand.NewSource(321))
+	b.Run("BTree/Range1000", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lo := r.Intn(scanSize)
+			sum := 0
+			for _, v := range bt.Range(lo, lo+1000) {
+				sum += v
+			}
+		}
+	})
+	b.Run("BPlusTree/Range1000", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lo := r.Intn(scanSize)
+			sum := 0
+			for _, v := range bp.Range(lo, lo+1000) {
+				sum += v
+			}
+		}
+	})
+}
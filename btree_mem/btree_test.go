@@ -1,6 +1,7 @@
 package btree_mem
 
 import (
+	"errors"
 	"math/rand"
 	"slices"
 	"testing"
@@ -314,6 +315,44 @@ func TestBTree_InsertSplitRootDuplicate(t *testing.T) {
 	}
 }
 
+// keys that end up in internal nodes were never found when search compared with < 1
+func TestBTree_SearchInternalKeys(t *testing.T) {
+	b := New[int, int](2, func(a int, b int) int {
+		return a - b
+	})
+	for i := 0; i < 100; i += 2 {
+		b.Insert(i, i*10)
+	}
+
+	var internal []int
+	var walk func(x *node[int, int])
+	walk = func(x *node[int, int]) {
+		if x.leaf {
+			return
+		}
+		for i := 0; i < x.n; i++ {
+			internal = append(internal, x.keys[i].key)
+		}
+		for i := 0; i <= x.n; i++ {
+			walk(x.children[i])
+		}
+	}
+	walk(b.root)
+	if len(internal) == 0 {
+		t.Fatalf("expected keys in internal nodes, height is %d", b.height)
+	}
+
+	for _, k := range internal {
+		if v, found := b.Search(k); !found || v != k*10 {
+			t.Errorf("Search(%d) in an internal node returned (%d, %v), expected (%d, true)", k, v, found, k*10)
+		}
+		// the odd neighbours fall between the separators and are not in the tree
+		if _, found := b.Search(k + 1); found {
+			t.Errorf("Search(%d) found a key that was never inserted", k+1)
+		}
+	}
+}
+
 func validateInsertsHelper(t *testing.T, b *BTree[int, int], inserts []int) {
 
 	var treeResult []int
@@ -335,3 +374,299 @@ func valueSlice[K any, V any](b *BTree[K, V]) []V {
 	})
 	return x
 }
+
+func TestBTree_Validate(t *testing.T) {
+	for _, degree := range []int{2, 3, 13} {
+		b := New[int, int](degree, func(a int, b int) int {
+			return a - b
+		})
+		r := rand.New(rand.NewSource(321))
+
+		if err := b.Validate(); err != nil {
+			t.Errorf("empty tree should be valid, got %v", err)
+		}
+		for i := 0; i < 2000; i++ {
+			b.Insert(r.Intn(1000), i)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("degree %d valid tree reported %v", degree, err)
+		}
+	}
+
+	build := func() *BTree[int, int] {
+		b := New[int, int](2, func(a int, b int) int {
+			return a - b
+		})
+		for i := 0; i < 20; i++ {
+			b.Insert(i, i)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(b *BTree[int, int])
+		path    string
+	}{
+		{"size", func(b *BTree[int, int]) { b.size++ }, "root"},
+		{"height", func(b *BTree[int, int]) { b.height++ }, "root/0/0/0"},
+		{"sorted", func(b *BTree[int, int]) {
+			x := b.root.children[0]
+			x.keys[0], x.keys[1] = x.keys[1], x.keys[0]
+		}, "root/0"},
+		{"separator", func(b *BTree[int, int]) { b.root.children[1].children[0].keys[0].key = -1 }, "root/1/0"},
+		{"underfull", func(b *BTree[int, int]) { b.root.children[0].children[0].n = 0 }, "root/0/0"},
+	}
+	for _, tt := range tests {
+		b := build()
+		tt.corrupt(b)
+
+		err := b.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a ValidationError, got %v", tt.name, err)
+			continue
+		}
+		if verr.Path != tt.path {
+			t.Errorf("%s: expected the error at %s, got %v", tt.name, tt.path, verr)
+		}
+	}
+}
+
+func TestBTree_FromSorted(t *testing.T) {
+	cmp := func(a int, b int) int {
+		return a - b
+	}
+	for _, degree := range []int{2, 3, 20} {
+		for _, n := range []int{0, 1, 2, 3, 4, 5, 39, 40, 41, 1000, 5000} {
+			b, err := FromSorted(degree, cmp, func(yield func(int, int) bool) {
+				for i := 0; i < n; i++ {
+					if !yield(i*2, i) {
+						return
+					}
+				}
+			})
+			if err != nil {
+				t.Fatalf("degree=%d n=%d: %v", degree, n, err)
+			}
+			if err := b.Validate(); err != nil {
+				t.Errorf("degree=%d n=%d: %v", degree, n, err)
+			}
+			if b.Size() != n {
+				t.Errorf("degree=%d n=%d: size is %d", degree, n, b.Size())
+			}
+			expected := make([]int, n)
+			for i := range expected {
+				expected[i] = i
+			}
+			if got := valueSlice(b); !slices.Equal(got, expected) {
+				t.Errorf("degree=%d n=%d: values are %v", degree, n, got)
+			}
+			for i := 0; i < n; i++ {
+				if v, found := b.Search(i * 2); !found || v != i {
+					t.Errorf("degree=%d n=%d: Search(%d) returned (%d, %v)", degree, n, i*2, v, found)
+				}
+			}
+
+			// packed nodes mean fewer levels than inserting the same keys one by one
+			ins := New[int, int](degree, cmp)
+			for i := 0; i < n; i++ {
+				ins.Insert(i*2, i)
+			}
+			if b.Height() > ins.Height() {
+				t.Errorf("degree=%d n=%d: height is %d, inserting gave %d", degree, n, b.Height(), ins.Height())
+			}
+
+			for i := 0; i < n; i++ {
+				b.Insert(i*2+1, -i)
+			}
+			if err := b.Validate(); err != nil {
+				t.Errorf("degree=%d n=%d: after inserting, %v", degree, n, err)
+			}
+		}
+	}
+
+	_, err := FromSorted(3, cmp, func(yield func(int, int) bool) {
+		for _, k := range []int{1, 5, 4} {
+			if !yield(k, k) {
+				return
+			}
+		}
+	})
+	if !errors.Is(err, ErrUnsorted) {
+		t.Errorf("expected ErrUnsorted, got %v", err)
+	}
+}
+
+func TestBTree_Delete(t *testing.T) {
+	cmp := func(a int, b int) int {
+		return a - b
+	}
+	for _, degree := range []int{2, 3, 10} {
+		r := rand.New(rand.NewSource(123))
+		b := New[int, int](degree, cmp)
+		m := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(2000)
+			b.Insert(k, i)
+			m[k] = i
+		}
+		for i := 0; i < 4000; i++ {
+			k := r.Intn(2000)
+			old, found := b.Delete(k)
+			prev, ok := m[k]
+			if found != ok || old != prev {
+				t.Errorf("degree=%d: Delete(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, found, prev, ok)
+			}
+			delete(m, k)
+			if i%250 == 0 {
+				if err := b.Validate(); err != nil {
+					t.Fatalf("degree=%d: after %d deletes, %v", degree, i, err)
+				}
+			}
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("degree=%d: %v", degree, err)
+		}
+		if b.Size() != len(m) {
+			t.Errorf("degree=%d: size is %d, expected %d", degree, b.Size(), len(m))
+		}
+		for k, v := range m {
+			if got, found := b.Search(k); !found || got != v {
+				t.Errorf("degree=%d: Search(%d) returned (%d, %v), expected (%d, true)", degree, k, got, found, v)
+			}
+		}
+
+		for k := range m {
+			b.Delete(k)
+		}
+		if b.Size() != 0 || b.Height() != 0 {
+			t.Errorf("degree=%d: emptied tree has size %d and height %d", degree, b.Size(), b.Height())
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("degree=%d: emptied tree, %v", degree, err)
+		}
+		if _, found := b.Delete(1); found {
+			t.Errorf("degree=%d: Delete on an empty tree should not find anything", degree)
+		}
+	}
+}
+
+func TestBTree_PopMinMax(t *testing.T) {
+	b := New[int, int](3, func(a int, b int) int {
+		return a - b
+	})
+	r := rand.New(rand.NewSource(321))
+	for _, k := range r.Perm(200) {
+		b.Insert(k, -k)
+	}
+	for i := 0; i < 100; i++ {
+		k, v, found := b.PopMin()
+		if !found || k != i || v != -i {
+			t.Errorf("PopMin returned (%d, %d, %v), expected (%d, %d, true)", k, v, found, i, -i)
+		}
+		k, v, found = b.PopMax()
+		if !found || k != 199-i || v != i-199 {
+			t.Errorf("PopMax returned (%d, %d, %v), expected (%d, %d, true)", k, v, found, 199-i, i-199)
+		}
+		if err := b.Validate(); err != nil {
+			t.Fatalf("after popping %d, %v", i, err)
+		}
+	}
+	if _, _, found := b.PopMin(); found {
+		t.Errorf("PopMin on an empty tree should not find anything")
+	}
+	if _, _, found := b.PopMax(); found {
+		t.Errorf("PopMax on an empty tree should not find anything")
+	}
+}
+
+func TestBTree_Iterators(t *testing.T) {
+	for _, degree := range []int{2, 3, 16} {
+		b := New[int, int](degree, func(a int, b int) int {
+			return a - b
+		})
+		r := rand.New(rand.NewSource(123))
+		var keys []int
+		for _, k := range r.Perm(300) {
+			// only even keys, so odd bounds fall between keys
+			b.Insert(k*2, -k*2)
+			keys = append(keys, k*2)
+		}
+		slices.Sort(keys)
+
+		var got []int
+		for k, v := range b.All() {
+			if v != -k {
+				t.Errorf("degree=%d: All yielded (%d, %d)", degree, k, v)
+			}
+			got = append(got, k)
+		}
+		if !slices.Equal(got, keys) {
+			t.Errorf("degree=%d: All yielded %v", degree, got)
+		}
+
+		for i := 0; i < 200; i++ {
+			lo, hi := r.Intn(620)-10, r.Intn(620)-10
+			var expected []int
+			for _, k := range keys {
+				if k >= lo && k < hi {
+					expected = append(expected, k)
+				}
+			}
+			got = got[:0]
+			for k := range b.Range(lo, hi) {
+				got = append(got, k)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("degree=%d: Range(%d, %d) yielded %v, expected %v", degree, lo, hi, got, expected)
+			}
+
+			expected = expected[:0]
+			for _, k := range slices.Backward(keys) {
+				if k > lo && k <= hi {
+					expected = append(expected, k)
+				}
+			}
+			got = got[:0]
+			for k := range b.Descend(hi, lo) {
+				got = append(got, k)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("degree=%d: Descend(%d, %d) yielded %v, expected %v", degree, hi, lo, got, expected)
+			}
+		}
+
+		// stopping early
+		got = got[:0]
+		for k := range b.Range(101, 1000) {
+			got = append(got, k)
+			if len(got) == 5 {
+				break
+			}
+		}
+		if !slices.Equal(got, []int{102, 104, 106, 108, 110}) {
+			t.Errorf("degree=%d: Range with break yielded %v", degree, got)
+		}
+		got = got[:0]
+		for k := range b.Descend(100, 0) {
+			got = append(got, k)
+			if len(got) == 3 {
+				break
+			}
+		}
+		if !slices.Equal(got, []int{100, 98, 96}) {
+			t.Errorf("degree=%d: Descend with break yielded %v", degree, got)
+		}
+	}
+
+	empty := New[int, int](3, func(a int, b int) int {
+		return a - b
+	})
+	for range empty.All() {
+		t.Errorf("empty tree should yield nothing")
+	}
+	for range empty.Descend(10, 0) {
+		t.Errorf("empty tree should yield nothing")
+	}
+}
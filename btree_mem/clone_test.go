@@ -0,0 +1,140 @@
+package btree_mem
+
+import (
+	"maps"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func checkContents(t *testing.T, name string, b *BTree[int, int], m map[int]int) {
+	t.Helper()
+	if err := b.Validate(); err != nil {
+		t.Errorf("%s: %v", name, err)
+	}
+	if b.Size() != len(m) {
+		t.Errorf("%s: size is %d, expected %d", name, b.Size(), len(m))
+	}
+	for k, v := range b.All() {
+		if m[k] != v {
+			t.Errorf("%s: has (%d, %d), expected (%d, %d)", name, k, v, k, m[k])
+		}
+	}
+}
+
+func TestBTree_Clone(t *testing.T) {
+	for _, degree := range []int{2, 5} {
+		r := rand.New(rand.NewSource(123))
+		b := New[int, int](degree, intcmp)
+		m := map[int]int{}
+		for i := 0; i < 1000; i++ {
+			k := r.Intn(1000)
+			b.Insert(k, i)
+			m[k] = i
+		}
+
+		c := b.Clone()
+		cm := maps.Clone(m)
+		// insert, replace and delete on both sides, each must leave the other alone
+		for i := 0; i < 2000; i++ {
+			k := r.Intn(1200)
+			switch r.Intn(4) {
+			case 0:
+				b.Insert(k, -i)
+				m[k] = -i
+			case 1:
+				c.Insert(k, i)
+				cm[k] = i
+			case 2:
+				b.Delete(k)
+				delete(m, k)
+			case 3:
+				c.Delete(k)
+				delete(cm, k)
+			}
+		}
+		checkContents(t, "original", b, m)
+		checkContents(t, "clone", c, cm)
+
+		// clones of clones
+		d := c.Clone()
+		dm := maps.Clone(cm)
+		for k := range 1200 {
+			d.Delete(k)
+		}
+		clear(dm)
+		d.Insert(1, 1)
+		dm[1] = 1
+		checkContents(t, "clone of clone", d, dm)
+		checkContents(t, "clone", c, cm)
+		checkContents(t, "original", b, m)
+	}
+}
+
+func TestBTree_CloneUntouched(t *testing.T) {
+	b := New[int, int](3, intcmp)
+	for i := 0; i < 100; i++ {
+		b.Insert(i, i)
+	}
+	c := b.Clone()
+	if c.root != b.root {
+		t.Errorf("clone should share the root until written")
+	}
+	c.Insert(50, -50)
+	if c.root == b.root {
+		t.Errorf("writing the clone should copy the root")
+	}
+	if v, _ := b.Search(50); v != 50 {
+		t.Errorf("original sees the clone's write, got %d", v)
+	}
+	// only the path to 50 was copied, the rest is still shared
+	shared := 0
+	for i := 0; i <= c.root.n; i++ {
+		if c.root.children[i] == b.root.children[i] {
+			shared++
+		}
+	}
+	if shared != c.root.n {
+		t.Errorf("expected %d children of the root to be shared, got %d", c.root.n, shared)
+	}
+}
+
+// run with -race, readers of a snapshot while the original is written
+func TestBTree_CloneConcurrentRead(t *testing.T) {
+	b := New[int, int](4, intcmp)
+	for i := 0; i < 5000; i++ {
+		b.Insert(i, i)
+	}
+	snap := b.Clone()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				n := 0
+				for k, v := range snap.All() {
+					if k != v {
+						t.Errorf("snapshot has (%d, %d)", k, v)
+						return
+					}
+					n++
+				}
+				if n != 5000 {
+					t.Errorf("snapshot has %d keys, expected 5000", n)
+					return
+				}
+			}
+		}()
+	}
+	r := rand.New(rand.NewSource(321))
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(10000)
+		if i%2 == 0 {
+			b.Insert(k, -k)
+		} else {
+			b.Delete(k)
+		}
+	}
+	wg.Wait()
+}
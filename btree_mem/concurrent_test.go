@@ -0,0 +1,221 @@
+package btree_mem
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrent_Sequential(t *testing.T) {
+	for _, degree := range []int{2, 3, 10} {
+		r := rand.New(rand.NewSource(123))
+		c := NewConcurrent[int, int](degree, intcmp)
+		m := map[int]int{}
+		for i := 0; i < 6000; i++ {
+			k := r.Intn(2000)
+			if r.Intn(3) == 0 {
+				old, found := c.Delete(k)
+				prev, ok := m[k]
+				if found != ok || old != prev {
+					t.Errorf("degree=%d: Delete(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, found, prev, ok)
+				}
+				delete(m, k)
+			} else {
+				old, replaced := c.Insert(k, i)
+				prev, ok := m[k]
+				if replaced != ok || old != prev {
+					t.Errorf("degree=%d: Insert(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, replaced, prev, ok)
+				}
+				m[k] = i
+			}
+		}
+		if err := c.Validate(); err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		for k := 0; k < 2000; k++ {
+			v, found := c.Search(k)
+			if prev, ok := m[k]; found != ok || v != prev {
+				t.Errorf("degree=%d: Search(%d) returned (%d, %v), expected (%d, %v)", degree, k, v, found, prev, ok)
+			}
+		}
+		for k := range m {
+			c.Delete(k)
+		}
+		if c.Size() != 0 || c.Height() != 0 {
+			t.Errorf("degree=%d: emptied tree has size %d and height %d", degree, c.Size(), c.Height())
+		}
+	}
+}
+
+// run with -race. each writer owns the keys equal to its id mod the number of
+// writers, so the final contents are known, while readers search across all of them.
+// writers record every pair before inserting it, so readers can tell a value that was
+// never written, or was written under another key
+func TestConcurrent_Stress(t *testing.T) {
+	const writers, readers, ops, keys = 8, 4, 4000, 4000
+	for _, degree := range []int{2, 4} {
+		c := NewConcurrent[int, int](degree, intcmp)
+		results := make([]map[int]int, writers)
+		var written sync.Map // [2]int{k, v} -> the writer
+		var wg sync.WaitGroup
+		var stop atomic.Bool
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(w)))
+				m := map[int]int{}
+				for i := 0; i < ops; i++ {
+					k := r.Intn(keys/writers)*writers + w
+					if r.Intn(3) == 0 {
+						c.Delete(k)
+						delete(m, k)
+					} else {
+						written.Store([2]int{k, k + i}, w)
+						c.Insert(k, k+i)
+						m[k] = k + i
+					}
+				}
+				results[w] = m
+			}()
+		}
+		var rg sync.WaitGroup
+		for g := 0; g < readers; g++ {
+			rg.Add(1)
+			go func() {
+				defer rg.Done()
+				r := rand.New(rand.NewSource(int64(100 + g)))
+				for !stop.Load() {
+					k := r.Intn(keys)
+					v, found := c.Search(k)
+					if !found {
+						continue
+					}
+					if d := v - k; d < 0 || d >= ops {
+						t.Errorf("Search(%d) returned %d, outside of what any writer inserts for it", k, v)
+						return
+					}
+					if w, ok := written.Load([2]int{k, v}); !ok || w != k%writers {
+						t.Errorf("Search(%d) returned %d, which writer %d never inserted", k, v, k%writers)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		stop.Store(true)
+		rg.Wait()
+
+		if err := c.Validate(); err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		total := 0
+		for _, m := range results {
+			total += len(m)
+			for k, v := range m {
+				if got, found := c.Search(k); !found || got != v {
+					t.Errorf("degree=%d: Search(%d) returned (%d, %v), expected (%d, true)", degree, k, got, found, v)
+				}
+			}
+		}
+		if c.Size() != total {
+			t.Errorf("degree=%d: size is %d, expected %d", degree, c.Size(), total)
+		}
+	}
+}
+
+// writers that neither split nor shrink the root only share its latches, so they get
+// past a reader that is holding on to the root
+func TestConcurrent_SharedRoot(t *testing.T) {
+	c := NewConcurrent[int, int](4, intcmp)
+	for k := 0; k < 200; k += 4 {
+		c.Insert(k, k)
+	}
+	if c.root.n == 2*c.degree-1 {
+		t.Fatalf("root is full, pick another setup")
+	}
+	c.mu.RLock()
+	c.root.latch.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// all in the leaf [0 4 8], which has room for 1 and then a key to spare for 8
+		c.Insert(1, 1)
+		c.Insert(4, 5)
+		c.Delete(8)
+		c.Delete(3)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("writers waited on the root latches")
+	}
+	c.root.latch.RUnlock()
+	c.mu.RUnlock()
+	<-done
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// lockedBTree is the single mutex approach Concurrent replaces
+type lockedBTree struct {
+	mu sync.RWMutex
+	b  *BTree[int, int]
+}
+
+func (l *lockedBTree) Search(k int) (int, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.b.Search(k)
+}
+
+func (l *lockedBTree) Insert(k, v int) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Insert(k, v)
+}
+
+type concurrentMap interface {
+	Search(k int) (int, bool)
+	Insert(k, v int) (int, bool)
+}
+
+// benchmarkMixed runs searches with one write in every writeEvery operations
+func benchmarkMixed(b *testing.B, m concurrentMap, writeEvery int) {
+	const keys = 1_000_000
+	for i := 0; i < keys; i += 2 {
+		m.Insert(i, i)
+	}
+	var seed atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(seed.Add(1)))
+		i := 0
+		for pb.Next() {
+			k := r.Intn(keys)
+			if i%writeEvery == 0 {
+				m.Insert(k, k)
+			} else {
+				m.Search(k)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkConcurrent(b *testing.B) {
+	for _, mix := range []struct {
+		name       string
+		writeEvery int
+	}{{"reads", 1 << 30}, {"10pct-writes", 10}, {"writes", 1}} {
+		b.Run("Mutex/"+mix.name, func(b *testing.B) {
+			benchmarkMixed(b, &lockedBTree{b: New[int, int](32, intcmp)}, mix.writeEvery)
+		})
+		b.Run("Latched/"+mix.name, func(b *testing.B) {
+			benchmarkMixed(b, NewConcurrent[int, int](32, intcmp), mix.writeEvery)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package btree_mem
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestOrdered(t *testing.T) {
+	for _, degree := range []int{2, 3, 32} {
+		r := rand.New(rand.NewSource(123))
+		o := NewOrdered[int, int](degree)
+		m := map[int]int{}
+		for i := 0; i < 5000; i++ {
+			k := r.Intn(3000)
+			if r.Intn(4) == 0 {
+				o.Delete(k)
+				delete(m, k)
+				continue
+			}
+			old, replaced := o.Insert(k, i)
+			prev, ok := m[k]
+			if replaced != ok || old != prev {
+				t.Errorf("degree=%d: Insert(%d) returned (%d, %v), expected (%d, %v)", degree, k, old, replaced, prev, ok)
+			}
+			m[k] = i
+		}
+		if err := o.Validate(); err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		if o.Size() != len(m) {
+			t.Errorf("degree=%d: size is %d, expected %d", degree, o.Size(), len(m))
+		}
+		for k := -1; k <= 3000; k++ {
+			v, found := o.Search(k)
+			if prev, ok := m[k]; found != ok || v != prev {
+				t.Errorf("degree=%d: Search(%d) returned (%d, %v), expected (%d, %v)", degree, k, v, found, prev, ok)
+			}
+		}
+
+		c := o.Clone()
+		c.Insert(-5, -5)
+		if _, found := o.Search(-5); found {
+			t.Errorf("degree=%d: insert into the clone is visible in the original", degree)
+		}
+	}
+
+	s := NewOrdered[string, int](2)
+	for i, w := range []string{"pear", "apple", "fig", "kiwi", "date", "apple"} {
+		s.Insert(w, i)
+	}
+	var got []string
+	for k := range s.All() {
+		got = append(got, k)
+	}
+	if fmt.Sprint(got) != "[apple date fig kiwi pear]" {
+		t.Errorf("string keys are out of order: %v", got)
+	}
+	if v, _ := s.Search("apple"); v != 5 {
+		t.Errorf("Search(apple) returned %d, expected 5", v)
+	}
+}
+
+// the compare func matches what callers of New usually pass
+func BenchmarkOrdered(b *testing.B) {
+	const n = 100_000
+	keys := rand.New(rand.NewSource(123)).Perm(n)
+	for _, degree := range []int{2, 32, 2000} {
+		b.Run(fmt.Sprintf("Insert/degree=%d/compare", degree), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				t := New[int, int](degree, intcmp)
+				for _, k := range keys {
+					t.Insert(k, k)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Insert/degree=%d/ordered", degree), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				t := NewOrdered[int, int](degree)
+				for _, k := range keys {
+					t.Insert(k, k)
+				}
+			}
+		})
+
+		generic := New[int, int](degree, intcmp)
+		ordered := NewOrdered[int, int](degree)
+		for _, k := range keys {
+			generic.Insert(k, k)
+			ordered.Insert(k, k)
+		}
+		b.Run(fmt.Sprintf("Search/degree=%d/compare", degree), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				generic.Search(keys[i%n])
+			}
+		})
+		b.Run(fmt.Sprintf("Search/degree=%d/ordered", degree), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ordered.Search(keys[i%n])
+			}
+		})
+	}
+}
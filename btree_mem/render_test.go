@@ -0,0 +1,109 @@
+package btree_mem
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBTree_WriteASCII(t *testing.T) {
+	render := func(n int) string {
+		b := New[int, int](2, func(a int, b int) int {
+			return a - b
+		})
+		for i := 1; i <= n; i++ {
+			b.Insert(i, i)
+		}
+		var sb strings.Builder
+		if err := b.WriteASCII(&sb); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+
+	// every child sits between the two separators that bound it
+	want := `        [11 12]
+    10
+    |   [9]
+    8
+    |   [7]
+    6
+        [5]
+4
+        [3]
+    2
+        [1]
+`
+	if got := render(12); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// a root with two keys, and the bars of both levels running past the children between them
+	want = `            [25 26]
+        24
+        |   [23]
+        22
+            [21]
+    20
+            [19]
+        18
+            [17]
+16
+|           [15]
+|       14
+|           [13]
+|   12
+|           [11]
+|       10
+|           [9]
+8
+            [7]
+        6
+            [5]
+    4
+            [3]
+        2
+            [1]
+`
+	if got := render(26); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got := render(0); got != "" {
+		t.Errorf("empty tree should write nothing, got %q", got)
+	}
+}
+
+func TestBTree_WriteDOT(t *testing.T) {
+	b := New[int, int](2, func(a int, b int) int {
+		return a - b
+	})
+	for i := 1; i <= 4; i++ {
+		b.Insert(i, i)
+	}
+
+	var sb strings.Builder
+	if err := b.WriteDOT(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph btree {
+	node [shape=record];
+	n0 [label="<c0>|2|<c1>"];
+	n1 [label="1"];
+	n0:c0 -> n1;
+	n2 [label="3|4"];
+	n0:c1 -> n2;
+}
+`
+	if sb.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	s := New[string, int](2, strings.Compare)
+	s.Insert("a|b", 1)
+	sb.Reset()
+	s.WriteDOT(&sb)
+	if !strings.Contains(sb.String(), `label="a\|b"`) {
+		t.Errorf("record characters should be escaped, got\n%s", sb.String())
+	}
+}
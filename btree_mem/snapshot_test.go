@@ -0,0 +1,297 @@
+package btree_mem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/rand"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBTree_Snapshot(t *testing.T) {
+	for _, degree := range []int{2, 3, 50} {
+		r := rand.New(rand.NewSource(123))
+		b := New[int, string](degree, intcmp)
+		b.SetCodecs(Varint[int]{}, String[string]{})
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(5000) - 2500
+			b.Insert(k, strconv.Itoa(k*3))
+		}
+		for i := 0; i < 500; i++ {
+			b.Delete(r.Intn(5000) - 2500)
+		}
+
+		var buf bytes.Buffer
+		n, err := b.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("degree=%d: WriteTo reported %d bytes, wrote %d", degree, n, buf.Len())
+		}
+
+		// the degree comes from the snapshot, not the tree read into
+		loaded := New[int, string](7, intcmp)
+		loaded.SetCodecs(Varint[int]{}, String[string]{})
+		loaded.Insert(99999, "replaced by the snapshot")
+		m, err := loaded.ReadFrom(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("degree=%d: %v", degree, err)
+		}
+		if m != n {
+			t.Errorf("degree=%d: ReadFrom reported %d bytes, expected %d", degree, m, n)
+		}
+		if err := loaded.Validate(); err != nil {
+			t.Errorf("degree=%d: %v", degree, err)
+		}
+		if loaded.Degree() != degree || loaded.Height() != b.Height() || loaded.Size() != b.Size() {
+			t.Errorf("degree=%d: loaded degree %d, height %d, size %d, expected %d, %d, %d",
+				degree, loaded.Degree(), loaded.Height(), loaded.Size(), degree, b.Height(), b.Size())
+		}
+		if got, want := entries(loaded), entries(b); !slices.Equal(got, want) {
+			t.Errorf("degree=%d: loaded entries differ from the original", degree)
+		}
+		// the loaded tree is an ordinary tree
+		loaded.Insert(99999, "x")
+		loaded.Delete(0)
+		if err := loaded.Validate(); err != nil {
+			t.Errorf("degree=%d: after modifying, %v", degree, err)
+		}
+	}
+}
+
+func entries[K any, V any](b *BTree[K, V]) []container[K, V] {
+	var out []container[K, V]
+	for k, v := range b.All() {
+		out = append(out, container[K, V]{key: k, val: v})
+	}
+	return out
+}
+
+func TestBTree_SnapshotErrors(t *testing.T) {
+	b := New[int, float64](3, intcmp)
+	if _, err := b.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Errorf("WriteTo without codecs should fail")
+	}
+	b.SetCodecs(Varint[int]{}, Float64{})
+	for i := 0; i < 100; i++ {
+		b.Insert(i, float64(i)/2)
+	}
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+
+	load := func(data []byte) (*BTree[int, float64], error) {
+		l := New[int, float64](3, intcmp)
+		l.SetCodecs(Varint[int]{}, Float64{})
+		l.Insert(-1, -1)
+		_, err := l.ReadFrom(bytes.NewReader(data))
+		if err != nil {
+			// a failed load leaves the tree as it was
+			if v, found := l.Search(-1); !found || v != -1 || l.Size() != 1 {
+				t.Errorf("failed ReadFrom changed the tree")
+			}
+		}
+		return l, err
+	}
+
+	flip := func(i int) []byte {
+		d := bytes.Clone(good)
+		d[i] ^= 0x40
+		return d
+	}
+	cases := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"empty", nil, "unexpected EOF"},
+		{"magic", flip(0), "not a snapshot"},
+		{"version", flip(4), "version"},
+		{"truncated", good[:len(good)-10], "unexpected EOF"},
+		{"no checksum", good[:len(good)-4], "unexpected EOF"},
+		{"checksum", flip(len(good) - 1), "checksum"},
+		{"payload", flip(len(good) / 2), ""},
+	}
+	for _, c := range cases {
+		_, err := load(c.data)
+		if !errors.Is(err, ErrBadSnapshot) {
+			t.Errorf("%s: expected ErrBadSnapshot, got %v", c.name, err)
+		} else if !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("%s: expected %q in %q", c.name, c.reason, err)
+		}
+	}
+
+	// written in one order, read with another
+	rev := New[int, float64](3, func(a, b int) int { return b - a })
+	rev.SetCodecs(Varint[int]{}, Float64{})
+	if _, err := rev.ReadFrom(bytes.NewReader(good)); !errors.Is(err, ErrBadSnapshot) {
+		t.Errorf("reading with a different order should fail, got %v", err)
+	}
+
+	if l, err := load(good); err != nil || l.Size() != 100 {
+		t.Errorf("good snapshot failed to load: %v", err)
+	}
+}
+
+// rawSnapshot writes a snapshot header and nodes by hand, with a correct checksum
+func rawSnapshot(degree, height, size uint64, nodes ...byte) []byte {
+	d := []byte(snapshotMagic)
+	d = append(d, snapshotVersion)
+	d = binary.AppendUvarint(d, degree)
+	d = binary.AppendUvarint(d, height)
+	d = binary.AppendUvarint(d, size)
+	d = append(d, nodes...)
+	return binary.BigEndian.AppendUint32(d, crc32.Checksum(d, castagnoli))
+}
+
+// allocated is the number of bytes f allocates
+func allocated(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// nothing is sized from the header, so a large degree or size only costs what is really read
+func TestBTree_SnapshotHostileHeader(t *testing.T) {
+	// one leaf with the key 7 and value 70
+	leaf := []byte{1, 1, 1, 14, 2, 140, 1}
+	// a header claiming a tree of a million keys per node, and nothing after it
+	header := func(degree, height, size uint64) []byte {
+		d := rawSnapshot(degree, height, size)
+		return d[:len(d)-4]
+	}
+	cases := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"large degree cut short", header(maxSnapshotDegree, 1, 1<<40), "unexpected EOF"},
+		{"size too large for height", rawSnapshot(2, 0, 10, leaf...), "do not fit"},
+		{"size too small for height", rawSnapshot(2, 2, 3, leaf...), "do not fit"},
+		{"size does not match keys", rawSnapshot(2, 0, 2, leaf...), "has 1 keys"},
+		{"empty internal root", rawSnapshot(2, 1, 3, 0, 0, 1, 0, 1, 0), "has 0 keys"},
+	}
+	for _, c := range cases {
+		b := New[int, int](2, intcmp)
+		b.SetCodecs(Varint[int]{}, Varint[int]{})
+		var err error
+		if n := allocated(func() { _, err = b.ReadFrom(bytes.NewReader(c.data)) }); n > 1<<20 {
+			t.Errorf("%s: allocated %d bytes", c.name, n)
+		}
+		if !errors.Is(err, ErrBadSnapshot) || !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("%s: expected ErrBadSnapshot with %q, got %v", c.name, c.reason, err)
+		}
+	}
+
+	// a well formed snapshot of a huge degree loads in nodes sized to their keys
+	b := New[int, int](2, intcmp)
+	b.SetCodecs(Varint[int]{}, Varint[int]{})
+	var err error
+	if n := allocated(func() { _, err = b.ReadFrom(bytes.NewReader(rawSnapshot(maxSnapshotDegree, 0, 1, leaf...))) }); n > 1<<20 {
+		t.Errorf("loading one key allocated %d bytes", n)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, found := b.Search(7); !found || v != 70 || b.degree != maxSnapshotDegree {
+		t.Errorf("Search(7) returned (%d, %v) at degree %d", v, found, b.degree)
+	}
+	if s := b.Stats(); s.KeySlots != 1 {
+		t.Errorf("loaded root has %d key slots, expected 1", s.KeySlots)
+	}
+}
+
+// loaded nodes only hold their keys, the first write to each copies it to full size
+func TestBTree_SnapshotCompactNodes(t *testing.T) {
+	src := New[int, int](4, intcmp)
+	src.SetCodecs(Varint[int]{}, Varint[int]{})
+	for i := 0; i < 500; i++ {
+		src.Insert(i, i)
+	}
+	var buf bytes.Buffer
+	if _, err := src.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b := New[int, int](4, intcmp)
+	b.SetCodecs(Varint[int]{}, Varint[int]{})
+	if _, err := b.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Stats(); s.KeySlots != s.UsedSlots || s.Keys != 500 {
+		t.Errorf("loaded tree has %d slots for %d keys", s.KeySlots, s.UsedSlots)
+	}
+	r := rand.New(rand.NewSource(321))
+	for i := 0; i < 1000; i++ {
+		k := r.Intn(1000)
+		if r.Intn(2) == 0 {
+			b.Insert(k, k)
+		} else {
+			b.Delete(k)
+		}
+	}
+	if err := b.Validate(); err != nil {
+		t.Error(err)
+	}
+	if s := b.Stats(); s.KeySlots <= s.UsedSlots {
+		t.Errorf("written nodes should have grown to full size, %d slots for %d keys", s.KeySlots, s.UsedSlots)
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	if _, err := (Varint[int8]{}).Decode((Varint[int]{}).Append(nil, 300)); err == nil {
+		t.Errorf("300 should not decode as an int8")
+	}
+	if v, err := (Uvarint[uint16]{}).Decode((Uvarint[uint16]{}).Append(nil, 65535)); err != nil || v != 65535 {
+		t.Errorf("Uvarint round trip returned (%d, %v)", v, err)
+	}
+	if _, err := (Float64{}).Decode([]byte{1, 2, 3}); err == nil {
+		t.Errorf("3 bytes should not decode as a float64")
+	}
+	type name string
+	if v, _ := (String[name]{}).Decode((String[name]{}).Append(nil, "go")); v != "go" {
+		t.Errorf("String round trip returned %q", v)
+	}
+}
+
+// loading a snapshot against inserting the same keys again
+func BenchmarkSnapshot(b *testing.B) {
+	const n = 100_000
+	src := New[int, int](32, intcmp)
+	src.SetCodecs(Varint[int]{}, Varint[int]{})
+	keys := rand.New(rand.NewSource(123)).Perm(n)
+	for _, k := range keys {
+		src.Insert(k, k)
+	}
+	var buf bytes.Buffer
+	src.WriteTo(&buf)
+	data := buf.Bytes()
+
+	b.Run("ReadFrom", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			t := New[int, int](32, intcmp)
+			t.SetCodecs(Varint[int]{}, Varint[int]{})
+			if _, err := t.ReadFrom(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			t := New[int, int](32, intcmp)
+			for _, k := range keys {
+				t.Insert(k, k)
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package btree_mem
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBTree_Stats(t *testing.T) {
+	empty := New[int, int](3, intcmp).Stats()
+	if empty.Nodes != 1 || empty.Leaves != 1 || empty.Keys != 0 || empty.AvgFill != 0 || empty.MinFill != 0 {
+		t.Errorf("empty tree stats %+v", empty)
+	}
+
+	// FromSorted packs 12 keys at degree 2 into leaves of 3, 2, 2 and 2 keys under a root of 3
+	b, err := FromSorted(2, intcmp, func(yield func(int, int) bool) {
+		for i := 0; i < 12; i++ {
+			if !yield(i, i) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := b.Stats()
+	if s.Height != 1 || s.Keys != 12 || s.Nodes != 5 || s.Leaves != 4 {
+		t.Errorf("stats %+v", s)
+	}
+	if !slices.Equal(s.NodesPerLevel, []int{1, 4}) {
+		t.Errorf("nodes per level %v, expected [1 4]", s.NodesPerLevel)
+	}
+	if s.KeySlots != 15 || s.UsedSlots != 12 {
+		t.Errorf("%d of %d slots used, expected 12 of 15", s.UsedSlots, s.KeySlots)
+	}
+	if s.AvgFill != 12.0/15 {
+		t.Errorf("average fill %f, expected %f", s.AvgFill, 12.0/15)
+	}
+	if s.MinFill != 2.0/3 {
+		t.Errorf("minimum fill %f, expected %f", s.MinFill, 2.0/3)
+	}
+	if s.MemoryBytes <= 0 || s.MemoryBytes%s.Nodes != 0 {
+		t.Errorf("memory estimate %d is not a multiple of the node count", s.MemoryBytes)
+	}
+	if !strings.Contains(s.String(), "12 keys in 5 nodes (4 leaves)") {
+		t.Errorf("report is\n%s", s)
+	}
+
+	// inserting in order leaves most nodes half full, a larger degree means fewer nodes
+	small, large := New[int, int](2, intcmp), New[int, int](32, intcmp)
+	for i := 0; i < 10000; i++ {
+		small.Insert(i, i)
+		large.Insert(i, i)
+	}
+	ss, ls := small.Stats(), large.Stats()
+	if ss.Keys != 10000 || ls.Keys != 10000 || ss.UsedSlots != 10000 {
+		t.Errorf("stats do not count every key, %d and %d", ss.UsedSlots, ls.UsedSlots)
+	}
+	if ls.Nodes >= ss.Nodes || ls.Height >= ss.Height {
+		t.Errorf("degree 32 has %d nodes of height %d, degree 2 has %d of height %d", ls.Nodes, ls.Height, ss.Nodes, ss.Height)
+	}
+	if len(ss.NodesPerLevel) != ss.Height+1 || ss.NodesPerLevel[ss.Height] != ss.Leaves {
+		t.Errorf("levels %v do not match height %d and %d leaves", ss.NodesPerLevel, ss.Height, ss.Leaves)
+	}
+}
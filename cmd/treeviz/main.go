@@ -0,0 +1,100 @@
+// treeviz reads keys from stdin, inserts them in order into a tree and prints it.
+// keys are separated by whitespace and are compared as integers unless -strings is set
+//
+//	echo 5 3 8 1 4 | go run ./cmd/treeviz -tree rb -format ascii
+//	seq 1 50 | go run ./cmd/treeviz -tree btree -degree 3 -format dot | dot -Tsvg > tree.svg
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/a-tk/go-datastructures/btree_mem"
+	"github.com/a-tk/go-datastructures/rbtree"
+)
+
+// renderer is the part of the trees that treeviz needs
+type renderer interface {
+	WriteDOT(io.Writer) error
+	WriteASCII(io.Writer) error
+}
+
+func main() {
+	tree := flag.String("tree", "rb", "tree to build: rb or btree")
+	format := flag.String("format", "ascii", "output format: ascii or dot")
+	degree := flag.Int("degree", 2, "minimum degree of the btree")
+	asStrings := flag.Bool("strings", false, "compare keys as strings instead of integers")
+	flag.Parse()
+
+	var words []string
+	sc := bufio.NewScanner(os.Stdin)
+	sc.Split(bufio.ScanWords)
+	for sc.Scan() {
+		words = append(words, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		fail(err)
+	}
+
+	var r renderer
+	var err error
+	if *asStrings {
+		r, err = build(words, *tree, *degree, func(s string) (string, error) { return s, nil })
+	} else {
+		r, err = build(words, *tree, *degree, strconv.Atoi)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	switch strings.ToLower(*format) {
+	case "ascii":
+		err = r.WriteASCII(os.Stdout)
+	case "dot":
+		err = r.WriteDOT(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func build[K cmp.Ordered](words []string, tree string, degree int, parse func(string) (K, error)) (renderer, error) {
+	var insert func(K)
+	var r renderer
+	switch strings.ToLower(tree) {
+	case "rb":
+		t := rbtree.New[K, struct{}](cmp.Compare[K])
+		insert = func(k K) { t.Insert(k, struct{}{}) }
+		r = t
+	case "btree":
+		if degree < 2 {
+			return nil, fmt.Errorf("degree must be at least 2, got %d", degree)
+		}
+		t := btree_mem.New[K, struct{}](degree, cmp.Compare[K])
+		insert = func(k K) { t.Insert(k, struct{}{}) }
+		r = t
+	default:
+		return nil, fmt.Errorf("unknown tree %q", tree)
+	}
+	for _, w := range words {
+		k, err := parse(w)
+		if err != nil {
+			return nil, err
+		}
+		insert(k)
+	}
+	return r, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "treeviz:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,92 @@
+// Package btstats computes Stats for btree and btree_mem. their nodes are different
+// types with the same shape, so each package describes its nodes with a visit func
+// and the walk is shared
+package btstats
+
+import "fmt"
+
+// Stats describes the shape of a tree, to help pick a degree for real data
+type Stats struct {
+	Degree        int
+	Height        int
+	Keys          int
+	Nodes         int
+	NodesPerLevel []int // index 0 is the root
+	Leaves        int
+	// fill is the fraction of a node's 2t-1 key capacity in use. the root is left out
+	// of MinFill, since it may hold as little as one key, unless it is the only node
+	AvgFill float64
+	MinFill float64
+	// KeySlots counts the slots allocated. a node allocates 2t-1 up front, whether it
+	// uses them or not, except one loaded by btree_mem's ReadFrom, which holds only its
+	// keys until written
+	KeySlots  int
+	UsedSlots int
+	// MemoryBytes estimates the nodes and their slices. anything a key or value points
+	// to, like the bytes of a string or the values btree holds by pointer, is not counted
+	MemoryBytes int
+}
+
+// Node is what visit reports about one node: its key count, whether it is a leaf,
+// its n+1 children when it is not, the key slots it allocated and its size in bytes
+type Node[N any] struct {
+	N        int
+	Leaf     bool
+	Children []N
+	Slots    int
+	Bytes    int
+}
+
+// Collect walks every node from root, so it is O(n / t)
+func Collect[N comparable](degree, height, keys int, root N, visit func(N) Node[N]) Stats {
+	s := Stats{
+		Degree: degree,
+		Height: height,
+		Keys:   keys,
+	}
+	s.MinFill = 1
+	slots := 2*degree - 1
+
+	var walk func(x N, depth int)
+	walk = func(x N, depth int) {
+		v := visit(x)
+		if depth == len(s.NodesPerLevel) {
+			s.NodesPerLevel = append(s.NodesPerLevel, 0)
+		}
+		s.NodesPerLevel[depth]++
+		s.Nodes++
+		s.UsedSlots += v.N
+		s.KeySlots += v.Slots
+		s.MemoryBytes += v.Bytes
+		fill := float64(v.N) / float64(slots)
+		if x != root && fill < s.MinFill {
+			s.MinFill = fill
+		}
+		if v.Leaf {
+			s.Leaves++
+			return
+		}
+		for _, c := range v.Children {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+
+	s.AvgFill = float64(s.UsedSlots) / float64(s.Nodes*slots)
+	if s.Nodes == 1 {
+		s.MinFill = s.AvgFill
+	}
+	return s
+}
+
+// String formats the stats as a short multi-line report
+func (s Stats) String() string {
+	return fmt.Sprintf("degree %d, height %d, %d keys in %d nodes (%d leaves)\n"+
+		"nodes per level %v\n"+
+		"fill avg %.1f%% min %.1f%%, %d of %d key slots used\n"+
+		"about %d bytes",
+		s.Degree, s.Height, s.Keys, s.Nodes, s.Leaves,
+		s.NodesPerLevel,
+		s.AvgFill*100, s.MinFill*100, s.UsedSlots, s.KeySlots,
+		s.MemoryBytes)
+}
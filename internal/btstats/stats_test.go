@@ -0,0 +1,57 @@
+// an external test, so it can build trees from both packages that use btstats.
+// it is also the only place btree.Stats runs, since the btree package's own tests do not compile
+package btstats_test
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/a-tk/go-datastructures/btree"
+	"github.com/a-tk/go-datastructures/btree_mem"
+)
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+// both packages split nodes the same way, so the same inserts give the same shape
+func TestStats_BothPackages(t *testing.T) {
+	for _, degree := range []int{2, 3, 16} {
+		disk := btree.NewBTree[int, int](degree, intcmp)
+		mem := btree_mem.New[int, int](degree, intcmp)
+		r := rand.New(rand.NewSource(123))
+		for i := 0; i < 5000; i++ {
+			k := r.Intn(10000)
+			disk.Insert(k, &k)
+			mem.Insert(k, k)
+		}
+
+		ds, ms := disk.Stats(), mem.Stats()
+		if ds.Keys != mem.Size() || ds.UsedSlots != ds.Keys {
+			t.Errorf("degree %d: btree counted %d keys in %d slots, expected %d", degree, ds.UsedSlots, ds.Keys, mem.Size())
+		}
+		if ds.Height != ms.Height || ds.Nodes != ms.Nodes || ds.Leaves != ms.Leaves || !slices.Equal(ds.NodesPerLevel, ms.NodesPerLevel) {
+			t.Errorf("degree %d: btree %+v and btree_mem %+v differ in shape", degree, ds, ms)
+		}
+		if ds.AvgFill != ms.AvgFill || ds.MinFill != ms.MinFill || ds.KeySlots != ms.KeySlots {
+			t.Errorf("degree %d: fill %f/%f and %f/%f differ", degree, ds.AvgFill, ds.MinFill, ms.AvgFill, ms.MinFill)
+		}
+		if ds.KeySlots != ds.Nodes*(2*degree-1) {
+			t.Errorf("degree %d: %d slots in %d nodes", degree, ds.KeySlots, ds.Nodes)
+		}
+		// btree holds values by pointer, a container is never larger than btree_mem's int pair
+		if ds.MemoryBytes <= 0 || ds.MemoryBytes > ms.MemoryBytes {
+			t.Errorf("degree %d: btree estimates %d bytes, btree_mem %d", degree, ds.MemoryBytes, ms.MemoryBytes)
+		}
+		if !strings.Contains(ds.String(), "key slots used") {
+			t.Errorf("report is\n%s", ds)
+		}
+	}
+
+	empty := btree.NewBTree[int, int](3, intcmp).Stats()
+	if empty.Nodes != 1 || empty.Leaves != 1 || empty.Keys != 0 || empty.AvgFill != 0 || empty.MinFill != 0 {
+		t.Errorf("empty tree stats %+v", empty)
+	}
+}
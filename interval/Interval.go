@@ -0,0 +1,129 @@
+package interval
+
+import (
+	"iter"
+
+	"github.com/a-tk/go-datastructures/rbtree"
+)
+
+// the tree is an rbtree.Augmented keyed on the interval, with the largest high endpoint
+// in each subtree as the aggregate (CLRS 14.3), so the red-black code and the upkeep of
+// max through rotations and removals are rbtree's.
+// a subtree whose max is below a query's low endpoint can not overlap it and is skipped
+
+// Interval is the closed interval [Low, High]
+type Interval[K any] struct {
+	Low, High K
+}
+
+// item is what the tree stores under an interval, with high set to the interval's own High.
+// as an aggregate, high is the largest High in the subtree and val is unused
+type item[K any, V any] struct {
+	val  V
+	high K
+	ok   bool // false only for the identity, the aggregate of an empty subtree
+}
+
+type Tree[K any, V any] struct {
+	t       *rbtree.Augmented[Interval[K], item[K, V]]
+	compare func(K, K) int
+}
+
+func New[K any, V any](compare func(K, K) int) *Tree[K, V] {
+	t := &Tree[K, V]{compare: compare}
+	t.t = rbtree.NewAugmented[Interval[K], item[K, V]](t.order, rbtree.Monoid[item[K, V]]{Combine: t.larger})
+	return t
+}
+
+// order sorts intervals by low endpoint, then by high endpoint, so intervals
+// that share a low endpoint are distinct keys
+func (t *Tree[K, V]) order(a, b Interval[K]) int {
+	if c := t.compare(a.Low, b.Low); c != 0 {
+		return c
+	}
+	return t.compare(a.High, b.High)
+}
+
+// larger keeps the larger high endpoint, it is the Combine of the max monoid
+func (t *Tree[K, V]) larger(a, b item[K, V]) item[K, V] {
+	if !b.ok || a.ok && t.compare(a.high, b.high) >= 0 {
+		return item[K, V]{high: a.high, ok: a.ok}
+	}
+	return item[K, V]{high: b.high, ok: true}
+}
+
+func (t *Tree[K, V]) overlaps(a, b Interval[K]) bool {
+	return t.compare(a.Low, b.High) <= 0 && t.compare(b.Low, a.High) <= 0
+}
+
+// Insert adds iv, replacing the value if the same interval is already present.
+// iv.Low must not be greater than iv.High
+func (t *Tree[K, V]) Insert(iv Interval[K], v V) (old V, replaced bool) {
+	prev, replaced := t.t.Insert(iv, item[K, V]{val: v, high: iv.High, ok: true})
+	return prev.val, replaced
+}
+
+func (t *Tree[K, V]) Remove(iv Interval[K]) (old V, found bool) {
+	prev, found := t.t.Remove(iv)
+	return prev.val, found
+}
+
+// Search finds the value stored for exactly the interval iv
+func (t *Tree[K, V]) Search(iv Interval[K]) (val V, found bool) {
+	x, found := t.t.Search(iv)
+	return x.val, found
+}
+
+// reaching scans, in order of low endpoint, the subtrees that hold some interval
+// ending at or after q.Low. every interval after one that starts past q.High does too,
+// so callers stop there
+func (t *Tree[K, V]) reaching(q Interval[K]) iter.Seq2[Interval[K], item[K, V]] {
+	return t.t.Scan(func(agg item[K, V]) bool {
+		return agg.ok && t.compare(agg.high, q.Low) >= 0
+	})
+}
+
+// AnyOverlap finds some interval that overlaps q in O(log n). only subtrees that reach q
+// are entered, so besides the first overlap in order, the scan only passes its ancestors
+func (t *Tree[K, V]) AnyOverlap(q Interval[K]) (iv Interval[K], val V, found bool) {
+	for x, it := range t.reaching(q) {
+		if t.compare(x.Low, q.High) > 0 {
+			break
+		}
+		if t.overlaps(x, q) {
+			return x, it.val, true
+		}
+	}
+	return iv, val, false
+}
+
+// AllOverlaps returns an iterator over every interval that overlaps q, in order of
+// low endpoint. subtrees that end before q or start after it are skipped, so a
+// query reporting m intervals costs O(min(n, m log n))
+func (t *Tree[K, V]) AllOverlaps(q Interval[K]) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		for x, it := range t.reaching(q) {
+			if t.compare(x.Low, q.High) > 0 {
+				return
+			}
+			if t.overlaps(x, q) && !yield(x, it.val) {
+				return
+			}
+		}
+	}
+}
+
+// Stab returns an iterator over every interval that contains p
+func (t *Tree[K, V]) Stab(p K) iter.Seq2[Interval[K], V] {
+	return t.AllOverlaps(Interval[K]{Low: p, High: p})
+}
+
+// Size is O(1)
+func (t *Tree[K, V]) Size() int {
+	return t.t.Size()
+}
+
+// Height counts the nodes on the longest path from the root, see rbtree.RBTree.Height
+func (t *Tree[K, V]) Height() int {
+	return t.t.Height()
+}
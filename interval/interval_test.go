@@ -0,0 +1,162 @@
+package interval
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+// checkTree verifies the red-black rules, and that the max of the whole tree is
+// the largest High stored
+func checkTree[V any](t *testing.T, tr *Tree[int, V]) {
+	t.Helper()
+	if err := tr.t.Validate(); err != nil {
+		t.Error(err)
+	}
+	count, high := 0, 0
+	for iv := range tr.t.Scan(func(item[int, V]) bool { return true }) {
+		count++
+		high = max(high, iv.High)
+	}
+	if count != tr.Size() {
+		t.Errorf("size is %d, tree has %d nodes", tr.Size(), count)
+	}
+	if total := tr.t.Total(); total.ok != (count > 0) || count > 0 && total.high != high {
+		t.Errorf("max of the tree is %v, expected %d", total, high)
+	}
+}
+
+func randomInterval(r *rand.Rand) Interval[int] {
+	lo := r.Intn(1000)
+	return Interval[int]{Low: lo, High: lo + r.Intn(50)}
+}
+
+func bruteOverlaps(ivs map[Interval[int]]int, q Interval[int]) []Interval[int] {
+	var out []Interval[int]
+	for iv := range ivs {
+		if iv.Low <= q.High && q.Low <= iv.High {
+			out = append(out, iv)
+		}
+	}
+	slices.SortFunc(out, func(a, b Interval[int]) int {
+		if a.Low != b.Low {
+			return a.Low - b.Low
+		}
+		return a.High - b.High
+	})
+	return out
+}
+
+func collect(seq func(func(Interval[int], int) bool)) []Interval[int] {
+	var out []Interval[int]
+	for iv := range seq {
+		out = append(out, iv)
+	}
+	return out
+}
+
+func TestTree_InsertRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(123))
+	tr := New[int, int](intcmp)
+	m := map[Interval[int]]int{}
+	for i := 0; i < 2000; i++ {
+		iv := randomInterval(r)
+		old, replaced := tr.Insert(iv, i)
+		prev, ok := m[iv]
+		if replaced != ok || old != prev {
+			t.Errorf("Insert(%v) returned (%d, %v), expected (%d, %v)", iv, old, replaced, prev, ok)
+		}
+		m[iv] = i
+	}
+	checkTree(t, tr)
+	for i := 0; i < 3000; i++ {
+		iv := randomInterval(r)
+		old, found := tr.Remove(iv)
+		prev, ok := m[iv]
+		if found != ok || old != prev {
+			t.Errorf("Remove(%v) returned (%d, %v), expected (%d, %v)", iv, old, found, prev, ok)
+		}
+		delete(m, iv)
+		if i%500 == 0 {
+			checkTree(t, tr)
+		}
+	}
+	checkTree(t, tr)
+	if tr.Size() != len(m) {
+		t.Errorf("size is %d, expected %d", tr.Size(), len(m))
+	}
+	for iv, v := range m {
+		if got, ok := tr.Search(iv); !ok || got != v {
+			t.Errorf("Search(%v) returned (%d, %v), expected (%d, true)", iv, got, ok, v)
+		}
+	}
+}
+
+func TestTree_Overlaps(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+	tr := New[int, int](intcmp)
+	m := map[Interval[int]]int{}
+	for i := 0; i < 500; i++ {
+		iv := randomInterval(r)
+		tr.Insert(iv, i)
+		m[iv] = i
+	}
+	for i := 0; i < 200; i++ {
+		iv := randomInterval(r)
+		tr.Remove(iv)
+		delete(m, iv)
+	}
+	for i := 0; i < 500; i++ {
+		q := randomInterval(r)
+		q.Low -= 10
+		expected := bruteOverlaps(m, q)
+		got := collect(tr.AllOverlaps(q))
+		if !slices.Equal(got, expected) {
+			t.Errorf("AllOverlaps(%v) returned %v, expected %v", q, got, expected)
+		}
+		iv, v, found := tr.AnyOverlap(q)
+		if found != (len(expected) > 0) {
+			t.Errorf("AnyOverlap(%v) found is %v, expected %v", q, found, len(expected) > 0)
+		} else if found && (m[iv] != v || !slices.Contains(expected, iv)) {
+			t.Errorf("AnyOverlap(%v) returned %v, which does not overlap", q, iv)
+		}
+		p := q.Low
+		expected = bruteOverlaps(m, Interval[int]{Low: p, High: p})
+		got = collect(tr.Stab(p))
+		if !slices.Equal(got, expected) {
+			t.Errorf("Stab(%d) returned %v, expected %v", p, got, expected)
+		}
+	}
+}
+
+func TestTree_OverlapsEarlyStop(t *testing.T) {
+	tr := New[int, string](intcmp)
+	tr.Insert(Interval[int]{1, 5}, "a")
+	tr.Insert(Interval[int]{2, 8}, "b")
+	tr.Insert(Interval[int]{6, 9}, "c")
+	tr.Insert(Interval[int]{10, 12}, "d")
+	var got []string
+	for _, v := range tr.AllOverlaps(Interval[int]{4, 11}) {
+		got = append(got, v)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", got)
+	}
+	got = got[:0]
+	for _, v := range tr.Stab(7) {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("Stab(7) expected [b c], got %v", got)
+	}
+	if _, _, found := tr.AnyOverlap(Interval[int]{13, 20}); found {
+		t.Errorf("AnyOverlap([13, 20]) should find nothing")
+	}
+}
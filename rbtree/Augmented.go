@@ -0,0 +1,161 @@
+package rbtree
+
+import "iter"
+
+// Monoid combines values for Augmented. Combine must be associative and Identity must
+// leave any value unchanged, e.g. (0, +) for sums or (math.MaxInt, min) for minimums.
+// Combine is always given its arguments in key order, so it need not be commutative
+type Monoid[V any] struct {
+	Identity V
+	Combine  func(V, V) V
+}
+
+// every node of an Augmented holds its own value and the combined value of its whole subtree
+type aval[V any] struct {
+	val V
+	agg V
+}
+
+// Augmented is a red-black tree that keeps a Monoid aggregate in every node, so the
+// combined value of any key range can be answered in O(log n). it is an RBTree whose
+// fix hook recomputes the aggregates, so the balancing code is RBTree's
+type Augmented[K any, V any] struct {
+	t    *RBTree[K, aval[V]]
+	m    Monoid[V]
+	size int
+}
+
+func NewAugmented[K any, V any](compare func(K, K) int, m Monoid[V]) *Augmented[K, V] {
+	a := &Augmented[K, V]{t: New[K, aval[V]](compare), m: m}
+	a.t.fix = a.update
+	return a
+}
+
+// agg is the aggregate of the subtree x. NIL is shared with other trees, so it can not
+// hold the identity of this tree's monoid
+func (a *Augmented[K, V]) agg(x *node[K, aval[V]]) V {
+	if x == a.t.NIL {
+		return a.m.Identity
+	}
+	return x.val.agg
+}
+
+// update recomputes the aggregate of x from its children
+func (a *Augmented[K, V]) update(x *node[K, aval[V]]) {
+	x.val.agg = a.m.Combine(a.m.Combine(a.agg(x.l), x.val.val), a.agg(x.r))
+}
+
+func (a *Augmented[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	prev, replaced := a.t.Insert(k, aval[V]{val: v})
+	if !replaced {
+		a.size++
+	}
+	return prev.val, replaced
+}
+
+func (a *Augmented[K, V]) Remove(k K) (old V, found bool) {
+	prev, found := a.t.Remove(k)
+	if found {
+		a.size--
+	}
+	return prev.val, found
+}
+
+func (a *Augmented[K, V]) Search(k K) (val V, found bool) {
+	x, found := a.t.Search(k)
+	return x.val, found
+}
+
+func (a *Augmented[K, V]) ContainsKey(k K) bool {
+	return a.t.ContainsKey(k)
+}
+
+// Aggregate combines the values of every key in [lo, hi), in key order, returning
+// the identity when the range is empty. the walk descends to the node where lo and hi
+// part ways, then follows one path toward each bound using whole subtree aggregates
+// along the way, so it is O(log n)
+func (a *Augmented[K, V]) Aggregate(lo, hi K) V {
+	t := a.t
+	if t.compare(lo, hi) >= 0 {
+		return a.m.Identity
+	}
+	x := t.root
+	for x != t.NIL {
+		if t.compare(x.key, lo) < 0 {
+			x = x.r
+		} else if t.compare(x.key, hi) >= 0 {
+			x = x.l
+		} else {
+			// lo <= x.key < hi
+			return a.m.Combine(a.m.Combine(a.from(x.l, lo), x.val.val), a.below(x.r, hi))
+		}
+	}
+	return a.m.Identity
+}
+
+// from combines every key >= lo in the subtree x
+func (a *Augmented[K, V]) from(x *node[K, aval[V]], lo K) V {
+	acc := a.m.Identity
+	// walking down, the pieces found are in descending key order, so they are prepended
+	for x != a.t.NIL {
+		if a.t.compare(x.key, lo) < 0 {
+			x = x.r
+		} else {
+			acc = a.m.Combine(a.m.Combine(x.val.val, a.agg(x.r)), acc)
+			x = x.l
+		}
+	}
+	return acc
+}
+
+// below combines every key < hi in the subtree x
+func (a *Augmented[K, V]) below(x *node[K, aval[V]], hi K) V {
+	acc := a.m.Identity
+	for x != a.t.NIL {
+		if a.t.compare(x.key, hi) >= 0 {
+			x = x.l
+		} else {
+			acc = a.m.Combine(acc, a.m.Combine(a.agg(x.l), x.val.val))
+			x = x.r
+		}
+	}
+	return acc
+}
+
+// Scan returns an iterator over the keys and values in ascending key order, skipping
+// every subtree whose aggregate does not satisfy keep. a key is not checked on its own,
+// it is yielded when the subtree below it is kept, so the caller still filters what it gets.
+// with a keep that only passes subtrees holding a match, finding the first match is O(log n)
+func (a *Augmented[K, V]) Scan(keep func(agg V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		a.scan(a.t.root, keep, yield)
+	}
+}
+
+// scan returns false once yield asks to stop
+func (a *Augmented[K, V]) scan(x *node[K, aval[V]], keep func(V) bool, yield func(K, V) bool) bool {
+	if x == a.t.NIL || !keep(x.val.agg) {
+		return true
+	}
+	return a.scan(x.l, keep, yield) && yield(x.key, x.val.val) && a.scan(x.r, keep, yield)
+}
+
+// Total is the aggregate of the whole tree, O(1)
+func (a *Augmented[K, V]) Total() V {
+	return a.agg(a.t.root)
+}
+
+// Size is O(1)
+func (a *Augmented[K, V]) Size() int {
+	return a.size
+}
+
+func (a *Augmented[K, V]) Height() int {
+	return a.t.Height()
+}
+
+// Validate checks the red-black properties like RBTree.Validate. V need not be
+// comparable, so aggregates are not checked
+func (a *Augmented[K, V]) Validate() error {
+	return a.t.Validate()
+}
@@ -0,0 +1,94 @@
+package rbtree
+
+// Cursor is a position in an RBTree that can move in both directions and remove the
+// entry it is on. a cursor starts off the tree, call First, Last or Seek to place it.
+// changing the tree other than through the cursor leaves the cursor undefined
+type Cursor[K any, V any] struct {
+	t *RBTree[K, V]
+	x *node[K, V]
+}
+
+func (t *RBTree[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{t: t, x: t.NIL}
+}
+
+// First moves to the smallest key, returning false if the tree is empty
+func (c *Cursor[K, V]) First() bool {
+	c.x = c.t.NIL
+	if c.t.root != c.t.NIL {
+		c.x = c.t.minimum(c.t.root)
+	}
+	return c.Valid()
+}
+
+// Last moves to the largest key, returning false if the tree is empty
+func (c *Cursor[K, V]) Last() bool {
+	c.x = c.t.NIL
+	if c.t.root != c.t.NIL {
+		c.x = c.t.maximum(c.t.root)
+	}
+	return c.Valid()
+}
+
+// Seek moves to the smallest key >= k, returning false if there is none
+func (c *Cursor[K, V]) Seek(k K) bool {
+	c.x = c.t.ceiling(k)
+	return c.Valid()
+}
+
+// Next moves to the next larger key, returning false when it walks off the end
+func (c *Cursor[K, V]) Next() bool {
+	if c.x != c.t.NIL {
+		c.x = c.t.successor(c.x)
+	}
+	return c.Valid()
+}
+
+// Prev moves to the next smaller key, returning false when it walks off the start
+func (c *Cursor[K, V]) Prev() bool {
+	if c.x != c.t.NIL {
+		c.x = c.t.predecessor(c.x)
+	}
+	return c.Valid()
+}
+
+// Valid reports whether the cursor is on an entry
+func (c *Cursor[K, V]) Valid() bool {
+	return c.x != c.t.NIL
+}
+
+// Key of the current entry, the zero value if the cursor is not valid
+func (c *Cursor[K, V]) Key() (k K) {
+	if c.x == c.t.NIL {
+		return k
+	}
+	return c.x.key
+}
+
+// Value of the current entry, the zero value if the cursor is not valid
+func (c *Cursor[K, V]) Value() (v V) {
+	if c.x == c.t.NIL {
+		return v
+	}
+	return c.x.val
+}
+
+// SetValue replaces the value of the current entry in place
+func (c *Cursor[K, V]) SetValue(v V) {
+	if c.x != c.t.NIL {
+		c.x.val = v
+	}
+}
+
+// Delete removes the current entry and moves to the next larger key, returning false
+// if there is none. remove relinks nodes rather than copying keys between them,
+// so the successor found before the delete is still the right node after it
+func (c *Cursor[K, V]) Delete() bool {
+	if c.x == c.t.NIL {
+		return false
+	}
+	next := c.t.successor(c.x)
+	c.t.remove(c.x)
+	c.x = next
+	return c.Valid()
+}
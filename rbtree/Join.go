@@ -0,0 +1,252 @@
+package rbtree
+
+// Join, Split and the set operations built on them follow the join-based
+// algorithms from "Just Join for Parallel Ordered Sets" (Blelloch, Ferizovic, Sun).
+// join walks down the spine of the taller tree to a black node with the same
+// black height as the shorter tree and hangs the shorter tree there, so it costs
+// O(difference in black height). every other operation is a sequence of joins.
+// the black heights are never counted again once an operation starts: split and
+// detach work them out for the pieces they cut off, and join for the tree it builds.
+// that keeps the joins in a split adding up to O(log n) instead of O(log n) each
+//
+// all of these consume their input trees. the nodes are relinked into the result
+// rather than copied, and the inputs are left empty.
+
+// Join builds a tree from every key in left, then k, then every key in right.
+// all keys in left must be less than k and all keys in right greater than k.
+// every tree of the same types shares one sentinel, so any two trees join in O(log n)
+func Join[K any, V any](left *RBTree[K, V], k K, v V, right *RBTree[K, V]) *RBTree[K, V] {
+	if left.root != left.NIL && left.compare(left.maximum(left.root).key, k) >= 0 ||
+		right.root != right.NIL && right.compare(right.minimum(right.root).key, k) <= 0 {
+		panic("rbtree: Join keys are out of order")
+	}
+	t := left.with(left.NIL)
+	t.root = t.join(t.subtree(left.root), t.newNode(k, v), t.subtree(right.root)).x
+	left.root = left.NIL
+	right.root = right.NIL
+	return t
+}
+
+// Split divides the tree into keys less than k and keys greater than or equal to k in O(log n).
+// t is left empty
+func (t *RBTree[K, V]) Split(k K) (left, right *RBTree[K, V]) {
+	l, m, r := t.split(t.subtree(t.root), k)
+	if m != t.NIL {
+		r = t.join(subtree[K, V]{x: t.NIL}, m, r)
+	}
+	t.root = t.NIL
+	return t.with(l.x), t.with(r.x)
+}
+
+// Union holds every key in a or b. when a key is in both, the value from a is kept
+func Union[K any, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	t := a.with(a.NIL)
+	t.root = t.union(t.subtree(a.root), t.subtree(b.root)).x
+	a.root = a.NIL
+	b.root = b.NIL
+	return t
+}
+
+// Intersection holds the keys that are in both a and b, with the values from a
+func Intersection[K any, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	t := a.with(a.NIL)
+	t.root = t.intersection(t.subtree(a.root), t.subtree(b.root)).x
+	a.root = a.NIL
+	b.root = b.NIL
+	return t
+}
+
+// Difference holds the keys in a that are not in b
+func Difference[K any, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	t := a.with(a.NIL)
+	t.root = t.difference(t.subtree(a.root), t.subtree(b.root)).x
+	a.root = a.NIL
+	b.root = b.NIL
+	return t
+}
+
+// with makes a tree out of root that shares t's sentinel and compare
+func (t *RBTree[K, V]) with(root *node[K, V]) *RBTree[K, V] {
+	return &RBTree[K, V]{root: root, compare: t.compare, NIL: t.NIL}
+}
+
+// subtree is a black rooted tree, or NIL, together with its black height h: the number
+// of black nodes on every path from x down to a leaf, not counting the sentinel
+type subtree[K any, V any] struct {
+	x *node[K, V]
+	h int
+}
+
+// subtree counts the black height of x, the only place it is counted, once per operation
+func (t *RBTree[K, V]) subtree(x *node[K, V]) subtree[K, V] {
+	h := 0
+	for y := x; y != t.NIL; y = y.l {
+		if y.color == BLACK {
+			h++
+		}
+	}
+	return subtree[K, V]{x: x, h: h}
+}
+
+// detach unlinks the children of s.x and returns them as black rooted trees.
+// s.x is left as a lone red node ready to be used by join
+func (t *RBTree[K, V]) detach(s subtree[K, V]) (l, r subtree[K, V]) {
+	x := s.x
+	// below x a path has one black node fewer if x is black, and a red child
+	// gains one when it is painted black
+	h := s.h
+	if x.color == BLACK {
+		h--
+	}
+	l, r = subtree[K, V]{x: x.l, h: h}, subtree[K, V]{x: x.r, h: h}
+	for _, c := range []*subtree[K, V]{&l, &r} {
+		if c.x != t.NIL {
+			c.x.p = t.NIL
+			if c.x.color == RED {
+				c.x.color = BLACK
+				c.h++
+			}
+		}
+	}
+	x.l, x.r, x.p = t.NIL, t.NIL, t.NIL
+	x.color = RED
+	return l, r
+}
+
+// join links the trees a and b through the lone node z and returns the new tree.
+// every key in a is less than z and every key in b is greater
+func (t *RBTree[K, V]) join(a subtree[K, V], z *node[K, V], b subtree[K, V]) subtree[K, V] {
+	z.color = RED
+	p := t.NIL
+	if a.h >= b.h {
+		// walk down the right spine of a to a black node with black height b.h
+		t.root = a.x
+		y, h := a.x, a.h
+		for y.color != BLACK || h != b.h {
+			if y.color == BLACK {
+				h--
+			}
+			p = y
+			y = y.r
+		}
+		if p == t.NIL {
+			t.root = z
+		} else {
+			p.r = z
+		}
+		z.l = y
+		z.r = b.x
+	} else {
+		t.root = b.x
+		y, h := b.x, b.h
+		for y.color != BLACK || h != a.h {
+			if y.color == BLACK {
+				h--
+			}
+			p = y
+			y = y.l
+		}
+		if p == t.NIL {
+			t.root = z
+		} else {
+			p.l = z
+		}
+		z.l = a.x
+		z.r = y
+	}
+	z.p = p
+	if z.l != t.NIL {
+		z.l.p = z
+	}
+	if z.r != t.NIL {
+		z.r.p = z
+	}
+	// hanging z keeps the black height of the taller tree, only painting a red root
+	// black adds to it
+	h := max(a.h, b.h)
+	if t.insertFixup(z) {
+		h++
+	}
+	return subtree[K, V]{x: t.root, h: h}
+}
+
+// join2 joins two trees without a middle node by pulling the maximum out of a
+func (t *RBTree[K, V]) join2(a, b subtree[K, V]) subtree[K, V] {
+	if a.x == t.NIL {
+		return b
+	}
+	l, m := t.splitLast(a)
+	return t.join(l, m, b)
+}
+
+// splitLast takes the node with the largest key out of the tree s, which is not empty,
+// the way split would, so the rest comes back with its black height
+func (t *RBTree[K, V]) splitLast(s subtree[K, V]) (rest subtree[K, V], last *node[K, V]) {
+	a, b := t.detach(s)
+	if b.x == t.NIL {
+		return a, s.x
+	}
+	r, last := t.splitLast(b)
+	return t.join(a, s.x, r), last
+}
+
+// split returns the trees of keys less than and greater than k, and the node holding k or NIL
+func (t *RBTree[K, V]) split(s subtree[K, V], k K) (l subtree[K, V], m *node[K, V], r subtree[K, V]) {
+	if s.x == t.NIL {
+		return s, t.NIL, s
+	}
+	x := s.x
+	a, b := t.detach(s)
+	cmp := t.compare(k, x.key)
+	if cmp == 0 {
+		return a, x, b
+	} else if cmp < 0 {
+		l, m, r = t.split(a, k)
+		return l, m, t.join(r, x, b)
+	} else {
+		l, m, r = t.split(b, k)
+		return t.join(a, x, l), m, r
+	}
+}
+
+func (t *RBTree[K, V]) union(a, b subtree[K, V]) subtree[K, V] {
+	if a.x == t.NIL {
+		return b
+	} else if b.x == t.NIL {
+		return a
+	}
+	x := a.x
+	al, ar := t.detach(a)
+	// a's value wins, a duplicate node from b is dropped
+	bl, _, br := t.split(b, x.key)
+	l := t.union(al, bl)
+	r := t.union(ar, br)
+	return t.join(l, x, r)
+}
+
+func (t *RBTree[K, V]) intersection(a, b subtree[K, V]) subtree[K, V] {
+	if a.x == t.NIL || b.x == t.NIL {
+		return subtree[K, V]{x: t.NIL}
+	}
+	x := a.x
+	al, ar := t.detach(a)
+	bl, m, br := t.split(b, x.key)
+	l := t.intersection(al, bl)
+	r := t.intersection(ar, br)
+	if m != t.NIL {
+		return t.join(l, x, r)
+	}
+	return t.join2(l, r)
+}
+
+func (t *RBTree[K, V]) difference(a, b subtree[K, V]) subtree[K, V] {
+	if a.x == t.NIL || b.x == t.NIL {
+		return a
+	}
+	x := b.x
+	bl, br := t.detach(b)
+	al, _, ar := t.split(a, x.key)
+	l := t.difference(al, bl)
+	r := t.difference(ar, br)
+	return t.join2(l, r)
+}
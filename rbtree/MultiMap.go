@@ -0,0 +1,83 @@
+package rbtree
+
+import "slices"
+
+// MultiMap is a red-black tree that allows duplicate keys. each key is stored once
+// with all of its values in insertion order, so the tree stays balanced on the
+// number of distinct keys no matter how many values share a key
+type MultiMap[K any, V any] struct {
+	tree *RBTree[K, []V]
+	size int
+}
+
+func NewMultiMap[K any, V any](compare func(K, K) int) *MultiMap[K, V] {
+	return &MultiMap[K, V]{tree: New[K, []V](compare), size: 0}
+}
+
+// Insert adds v after any values already stored under k
+func (m *MultiMap[K, V]) Insert(k K, v V) {
+	x := m.tree.search(m.tree.root, k)
+	if x == m.tree.NIL {
+		m.tree.Insert(k, []V{v})
+	} else {
+		x.val = append(x.val, v)
+	}
+	m.size++
+}
+
+// GetAll returns a copy of the values stored under k in insertion order, or nil
+func (m *MultiMap[K, V]) GetAll(k K) []V {
+	x := m.tree.search(m.tree.root, k)
+	if x == m.tree.NIL {
+		return nil
+	}
+	return slices.Clone(x.val)
+}
+
+// RemoveOne removes the first value under k for which pred returns true.
+// the key is removed with its last value
+func (m *MultiMap[K, V]) RemoveOne(k K, pred func(V) bool) (old V, found bool) {
+	x := m.tree.search(m.tree.root, k)
+	if x == m.tree.NIL {
+		return old, false
+	}
+	i := slices.IndexFunc(x.val, pred)
+	if i == -1 {
+		return old, false
+	}
+	old = x.val[i]
+	if len(x.val) == 1 {
+		m.tree.remove(x)
+	} else {
+		x.val = slices.Delete(x.val, i, i+1)
+	}
+	m.size--
+	return old, true
+}
+
+// RemoveAll removes k and returns all of its values in insertion order
+func (m *MultiMap[K, V]) RemoveAll(k K) (old []V, found bool) {
+	old, found = m.tree.Remove(k)
+	m.size -= len(old)
+	return old, found
+}
+
+// Count is the number of values stored under k
+func (m *MultiMap[K, V]) Count(k K) int {
+	vals, _ := m.tree.Search(k)
+	return len(vals)
+}
+
+func (m *MultiMap[K, V]) ContainsKey(k K) bool {
+	return m.tree.ContainsKey(k)
+}
+
+// Size is the number of values across all keys, O(1)
+func (m *MultiMap[K, V]) Size() int {
+	return m.size
+}
+
+// Height of the underlying tree, which only has a node per distinct key
+func (m *MultiMap[K, V]) Height() int {
+	return m.tree.Height()
+}
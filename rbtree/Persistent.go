@@ -0,0 +1,244 @@
+package rbtree
+
+import "iter"
+
+// Persistent is an immutable red-black tree. Insert and Remove never modify
+// a tree, they copy the nodes along the search path and return a new tree that
+// shares every other node with the old one, so old versions stay valid for readers.
+//
+// nodes have no parent pointers and there is no NIL sentinel, both would have to be
+// mutated on every change. the balancing follows Sedgewick's left-leaning red-black tree,
+// which only needs the path from the root, and is a valid red-black tree
+type Persistent[K any, V any] struct {
+	root    *pnode[K, V]
+	size    int
+	compare func(K, K) int
+}
+
+type pnode[K any, V any] struct {
+	key   K
+	val   V
+	l, r  *pnode[K, V]
+	color int
+}
+
+func NewPersistent[K any, V any](compare func(K, K) int) *Persistent[K, V] {
+	return &Persistent[K, V]{root: nil, size: 0, compare: compare}
+}
+
+// clone copies x so it can be changed without affecting older trees
+func clone[K any, V any](x *pnode[K, V]) *pnode[K, V] {
+	y := *x
+	return &y
+}
+
+func isRed[K any, V any](x *pnode[K, V]) bool {
+	return x != nil && x.color == RED
+}
+
+// Insert returns a new tree containing k, replacing the value if k is already present
+func (t *Persistent[K, V]) Insert(k K, v V) *Persistent[K, V] {
+	size := t.size
+	if !t.ContainsKey(k) {
+		size++
+	}
+	root := t.insert(t.root, k, v)
+	root.color = BLACK
+	return &Persistent[K, V]{root: root, size: size, compare: t.compare}
+}
+
+func (t *Persistent[K, V]) insert(h *pnode[K, V], k K, v V) *pnode[K, V] {
+	if h == nil {
+		return &pnode[K, V]{key: k, val: v, color: RED}
+	}
+	h = clone(h)
+	cmp := t.compare(k, h.key)
+	if cmp == 0 {
+		h.val = v
+	} else if cmp < 0 {
+		h.l = t.insert(h.l, k, v)
+	} else {
+		h.r = t.insert(h.r, k, v)
+	}
+	return t.fixUp(h)
+}
+
+// Remove returns a new tree without k. if k is not present the same tree is returned
+func (t *Persistent[K, V]) Remove(k K) *Persistent[K, V] {
+	if !t.ContainsKey(k) {
+		return t
+	}
+	root := clone(t.root)
+	if !isRed(root.l) && !isRed(root.r) {
+		root.color = RED
+	}
+	root = t.remove(root, k)
+	if root != nil {
+		root.color = BLACK
+	}
+	return &Persistent[K, V]{root: root, size: t.size - 1, compare: t.compare}
+}
+
+// remove assumes k is in the subtree rooted at h
+func (t *Persistent[K, V]) remove(h *pnode[K, V], k K) *pnode[K, V] {
+	h = clone(h)
+	if t.compare(k, h.key) < 0 {
+		if !isRed(h.l) && !isRed(h.l.l) {
+			h = t.moveRedLeft(h)
+		}
+		h.l = t.remove(h.l, k)
+	} else {
+		if isRed(h.l) {
+			h = t.rotateRight(h)
+		}
+		if t.compare(k, h.key) == 0 && h.r == nil {
+			return nil
+		}
+		if !isRed(h.r) && !isRed(h.r.l) {
+			h = t.moveRedRight(h)
+		}
+		if t.compare(k, h.key) == 0 {
+			// replace with the successor, then remove the successor from the right side
+			x := h.r
+			for x.l != nil {
+				x = x.l
+			}
+			h.key = x.key
+			h.val = x.val
+			h.r = t.removeMin(h.r)
+		} else {
+			h.r = t.remove(h.r, k)
+		}
+	}
+	return t.fixUp(h)
+}
+
+func (t *Persistent[K, V]) removeMin(h *pnode[K, V]) *pnode[K, V] {
+	if h.l == nil {
+		return nil
+	}
+	h = clone(h)
+	if !isRed(h.l) && !isRed(h.l.l) {
+		h = t.moveRedLeft(h)
+	}
+	h.l = t.removeMin(h.l)
+	return t.fixUp(h)
+}
+
+// the helpers below all expect h to already be a copy owned by the new tree
+
+func (t *Persistent[K, V]) rotateLeft(h *pnode[K, V]) *pnode[K, V] {
+	x := clone(h.r)
+	h.r = x.l
+	x.l = h
+	x.color = h.color
+	h.color = RED
+	return x
+}
+
+func (t *Persistent[K, V]) rotateRight(h *pnode[K, V]) *pnode[K, V] {
+	x := clone(h.l)
+	h.l = x.r
+	x.r = h
+	x.color = h.color
+	h.color = RED
+	return x
+}
+
+func (t *Persistent[K, V]) flipColors(h *pnode[K, V]) {
+	h.color = 1 - h.color
+	h.l = clone(h.l)
+	h.l.color = 1 - h.l.color
+	h.r = clone(h.r)
+	h.r.color = 1 - h.r.color
+}
+
+func (t *Persistent[K, V]) moveRedLeft(h *pnode[K, V]) *pnode[K, V] {
+	t.flipColors(h)
+	if isRed(h.r.l) {
+		h.r = t.rotateRight(h.r)
+		h = t.rotateLeft(h)
+		t.flipColors(h)
+	}
+	return h
+}
+
+func (t *Persistent[K, V]) moveRedRight(h *pnode[K, V]) *pnode[K, V] {
+	t.flipColors(h)
+	if isRed(h.l.l) {
+		h = t.rotateRight(h)
+		t.flipColors(h)
+	}
+	return h
+}
+
+func (t *Persistent[K, V]) fixUp(h *pnode[K, V]) *pnode[K, V] {
+	if isRed(h.r) && !isRed(h.l) {
+		h = t.rotateLeft(h)
+	}
+	if isRed(h.l) && isRed(h.l.l) {
+		h = t.rotateRight(h)
+	}
+	if isRed(h.l) && isRed(h.r) {
+		t.flipColors(h)
+	}
+	return h
+}
+
+func (t *Persistent[K, V]) Search(k K) (val V, found bool) {
+	x := t.root
+	for x != nil {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x.val, true
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return val, false
+}
+
+func (t *Persistent[K, V]) ContainsKey(k K) bool {
+	_, found := t.Search(k)
+	return found
+}
+
+// Size is O(1), every version records its own size
+func (t *Persistent[K, V]) Size() int {
+	return t.size
+}
+
+// Height counts the nodes on the longest path from the root, see RBTree.Height
+func (t *Persistent[K, V]) Height() int {
+	return t.height(t.root)
+}
+
+func (t *Persistent[K, V]) height(x *pnode[K, V]) int {
+	if x == nil {
+		return 0
+	}
+	return max(t.height(x.l), t.height(x.r)) + 1
+}
+
+// All returns an iterator over the keys and values of this version in ascending order.
+// without parent pointers the path back up is kept on a slice
+func (t *Persistent[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var s []*pnode[K, V]
+		x := t.root
+		for len(s) > 0 || x != nil {
+			for x != nil {
+				s = append(s, x)
+				x = x.l
+			}
+			x = s[len(s)-1]
+			s = s[:len(s)-1]
+			if !yield(x.key, x.val) {
+				return
+			}
+			x = x.r
+		}
+	}
+}
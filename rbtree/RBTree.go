@@ -1,5 +1,13 @@
 package rbtree
 
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math/bits"
+	"sync"
+)
+
 const (
 	RED   = 0
 	BLACK = 1
@@ -15,7 +23,13 @@ type node[K any, V any] struct {
 type RBTree[K any, V any] struct {
 	root    *node[K, V]
 	compare func(K, K) int
-	NIL     *node[K, V]
+	// NIL is the sentinel shared by every tree of the same types, see sentinel. it must
+	// never be written, a change to it would show up in all of those trees at once
+	NIL *node[K, V]
+	// fix recomputes whatever x keeps about its subtree from x and its children. it is
+	// called after a rotation, or on the path up from a node that gained or lost a
+	// descendant. nil for a plain tree, Augmented sets it
+	fix func(x *node[K, V])
 }
 
 func (t *RBTree[K, V]) newNode(k K, v V) *node[K, V] {
@@ -30,16 +44,70 @@ func (t *RBTree[K, V]) newNode(k K, v V) *node[K, V] {
 }
 
 func New[K any, V any](compare func(K, K) int) *RBTree[K, V] {
-	NIL := &node[K, V]{
+	NIL := sentinel[K, V]()
+	return &RBTree[K, V]{
+		NIL:     NIL,
+		root:    NIL,
+		compare: compare}
+}
+
+// sentinels holds one NIL per node type. nothing ever writes to NIL, not even its parent
+// pointer, so every tree of the same types can share it. that is what lets Join link two
+// unrelated trees without visiting their leaves, and trees that share it be changed concurrently
+var sentinels sync.Map
+
+func sentinel[K any, V any]() *node[K, V] {
+	// a nil pointer of each node type is a distinct key
+	key := (*node[K, V])(nil)
+	if s, ok := sentinels.Load(key); ok {
+		return s.(*node[K, V])
+	}
+	s, _ := sentinels.LoadOrStore(key, &node[K, V]{
 		l:     nil,
 		r:     nil,
 		p:     nil,
 		color: BLACK,
+	})
+	return s.(*node[K, V])
+}
+
+// ErrUnsorted is returned by FromSorted when the keys are not strictly increasing
+var ErrUnsorted = errors.New("rbtree: input is not sorted")
+
+// FromSorted builds a tree in O(n) from seq, which must yield strictly increasing keys.
+// the tree is as balanced as possible, so every leaf is on one of the last two levels.
+// every node is black except those on the last level, which are red, so each path
+// from the root to NIL has the same number of black nodes
+func FromSorted[K any, V any](compare func(K, K) int, seq iter.Seq2[K, V]) (*RBTree[K, V], error) {
+	t := New[K, V](compare)
+	var nodes []*node[K, V]
+	for k, v := range seq {
+		if n := len(nodes); n > 0 && compare(nodes[n-1].key, k) >= 0 {
+			return nil, fmt.Errorf("%w: key %v at position %d does not follow %v", ErrUnsorted, k, n, nodes[n-1].key)
+		}
+		nodes = append(nodes, t.newNode(k, v))
 	}
-	return &RBTree[K, V]{
-		NIL:     NIL,
-		root:    NIL,
-		compare: compare}
+	// a tree built by halving n nodes is exactly this tall
+	h := bits.Len(uint(len(nodes)))
+	t.root = t.build(nodes, t.NIL, 1, h)
+	return t, nil
+}
+
+func (t *RBTree[K, V]) build(nodes []*node[K, V], p *node[K, V], depth, h int) *node[K, V] {
+	if len(nodes) == 0 {
+		return t.NIL
+	}
+	mid := len(nodes) / 2
+	x := nodes[mid]
+	x.p = p
+	x.l = t.build(nodes[:mid], x, depth+1, h)
+	x.r = t.build(nodes[mid+1:], x, depth+1, h)
+	if depth == h && depth > 1 {
+		x.color = RED
+	} else {
+		x.color = BLACK
+	}
+	return x
 }
 
 func (t *RBTree[K, V]) Insert(k K, v V) (old V, replaced bool) {
@@ -52,7 +120,8 @@ func (t *RBTree[K, V]) Insert(k K, v V) (old V, replaced bool) {
 		if cmp == 0 {
 			prev := x.val
 			x.val = v
-			//tree was not mutated, no fixing needed
+			//shape did not change, no rebalancing needed
+			t.fixUp(x)
 			return prev, true
 		} else if cmp < 0 {
 			x = x.l
@@ -68,10 +137,24 @@ func (t *RBTree[K, V]) Insert(k K, v V) (old V, replaced bool) {
 	} else {
 		y.r = z
 	}
+	t.fixUp(z)
 	t.insertFixup(z)
 	return old, false
 }
-func (t *RBTree[K, V]) insertFixup(z *node[K, V]) {
+
+// fixUp runs fix on x and every ancestor
+func (t *RBTree[K, V]) fixUp(x *node[K, V]) {
+	if t.fix == nil {
+		return
+	}
+	for ; x != t.NIL; x = x.p {
+		t.fix(x)
+	}
+}
+
+// insertFixup restores the red-black properties above the red node z. it reports whether
+// the root ended up red and was painted black, the only way the black height grows
+func (t *RBTree[K, V]) insertFixup(z *node[K, V]) (grew bool) {
 	for z.p.color == RED {
 		if z.p == z.p.p.l {
 			y := z.p.p.r //z's uncle
@@ -107,7 +190,9 @@ func (t *RBTree[K, V]) insertFixup(z *node[K, V]) {
 			}
 		}
 	}
+	grew = t.root.color == RED
 	t.root.color = BLACK
+	return grew
 }
 
 func (t *RBTree[K, V]) leftRotate(x *node[K, V]) {
@@ -126,6 +211,11 @@ func (t *RBTree[K, V]) leftRotate(x *node[K, V]) {
 	}
 	y.l = x
 	x.p = y
+	// the same keys are under the pair, so only x, now the lower of the two, and then y change
+	if t.fix != nil {
+		t.fix(x)
+		t.fix(y)
+	}
 }
 
 func (t *RBTree[K, V]) rightRotate(x *node[K, V]) {
@@ -144,6 +234,10 @@ func (t *RBTree[K, V]) rightRotate(x *node[K, V]) {
 	}
 	y.r = x
 	x.p = y
+	if t.fix != nil {
+		t.fix(x)
+		t.fix(y)
+	}
 }
 
 // Height calculates how many nodes from the top of the tree
@@ -176,7 +270,9 @@ func (t *RBTree[K, V]) rbtransplant(u *node[K, V], v *node[K, V]) {
 	} else {
 		u.p.r = v
 	}
-	v.p = u.p
+	if v != t.NIL {
+		v.p = u.p
+	}
 }
 
 func (t *RBTree[K, V]) Remove(k K) (old V, found bool) {
@@ -192,85 +288,100 @@ func (t *RBTree[K, V]) Remove(k K) (old V, found bool) {
 func (t *RBTree[K, V]) remove(z *node[K, V]) {
 	y := z
 	var x *node[K, V]
+	// x may be NIL, which is shared and never written, so the parent it ends up under
+	// is kept in xp instead of x.p (CLRS sets NIL.p here)
+	var xp *node[K, V]
 	y_original_color := y.color
 	if z.l == t.NIL {
+		x = z.r
+		xp = z.p
 		t.rbtransplant(z, z.r)
 	} else if z.r == t.NIL {
 		x = z.l
+		xp = z.p
 		t.rbtransplant(z, z.l)
 	} else {
 		y := t.minimum(z.r)
 		y_original_color = y.color
 		x = y.r
 		if y != z.r {
+			xp = y.p
 			t.rbtransplant(y, y.r)
 			y.r = z.r
 			y.r.p = y
 		} else {
-			x.p = y
+			xp = y
 		}
 		t.rbtransplant(z, y)
 		y.l = z.l
 		y.l.p = y
 		y.color = z.color
 	}
+	// xp is also the lowest node that lost a descendant
+	t.fixUp(xp)
 	if y_original_color == BLACK {
-		t.removeFixup(x)
+		t.removeFixup(x, xp)
 	}
 }
-func (t *RBTree[K, V]) removeFixup(x *node[K, V]) {
+
+// removeFixup is CLRS RB-DELETE-FIXUP with the parent of x passed in as p
+func (t *RBTree[K, V]) removeFixup(x, p *node[K, V]) {
 	for x != t.root && x.color == BLACK {
-		if x == x.p.l {
-			w := x.p.r
+		if x == p.l {
+			w := p.r
 			if w.color == RED {
 				w.color = BLACK
-				x.p.color = RED
-				t.leftRotate(x.p)
-				w = x.p.r
+				p.color = RED
+				t.leftRotate(p)
+				w = p.r
 			}
 			if w.l.color == BLACK && w.r.color == BLACK {
 				w.color = RED
-				x = x.p
+				x = p
+				p = x.p
 			} else {
 				if w.r.color == BLACK {
 					w.l.color = BLACK
 					w.color = RED
 					t.rightRotate(w)
-					w = x.p.r
+					w = p.r
 				}
-				w.color = x.p.color
-				x.p.color = BLACK
+				w.color = p.color
+				p.color = BLACK
 				w.r.color = BLACK
-				t.leftRotate(x.p)
+				t.leftRotate(p)
 				x = t.root
 			}
 		} else {
-			w := x.p.l
+			w := p.l
 			if w.color == RED {
 				w.color = BLACK
-				x.p.color = RED
-				t.rightRotate(x.p)
-				w = x.p.l
+				p.color = RED
+				t.rightRotate(p)
+				w = p.l
 			}
 			if w.r.color == BLACK && w.l.color == BLACK {
 				w.color = RED
-				x = x.p
+				x = p
+				p = x.p
 			} else {
 				if w.l.color == BLACK {
 					w.r.color = BLACK
 					w.color = RED
 					t.leftRotate(w)
-					w = x.p.l
+					w = p.l
 				}
-				w.color = x.p.color
-				x.p.color = BLACK
+				w.color = p.color
+				p.color = BLACK
 				w.l.color = BLACK
-				t.rightRotate(x.p)
+				t.rightRotate(p)
 				x = t.root
 			}
 		}
 	}
-	x.color = BLACK
+	if x != t.NIL {
+		x.color = BLACK
+	}
 }
 
 func (t *RBTree[K, V]) Search(k K) (val V, found bool) {
@@ -352,6 +463,9 @@ func (t *RBTree[K, V]) maximum(x *node[K, V]) *node[K, V] {
 
 func (t *RBTree[K, V]) Successor(k K) (val V, found bool) {
 	x := t.search(t.root, k)
+	if x == t.NIL {
+		return val, false
+	}
 	x = t.successor(x)
 	if x != t.NIL {
 		return x.val, true
@@ -375,6 +489,9 @@ func (t *RBTree[K, V]) successor(x *node[K, V]) *node[K, V] {
 
 func (t *RBTree[K, V]) Predecessor(k K) (val V, found bool) {
 	x := t.search(t.root, k)
+	if x == t.NIL {
+		return val, false
+	}
 	x = t.predecessor(x)
 	if x != t.NIL {
 		return x.val, true
@@ -396,6 +513,118 @@ func (t *RBTree[K, V]) predecessor(x *node[K, V]) *node[K, V] {
 	}
 }
 
+// Min finds the smallest key in the tree
+func (t *RBTree[K, V]) Min() (k K, v V, found bool) {
+	if t.root == t.NIL {
+		return k, v, false
+	}
+	x := t.minimum(t.root)
+	return x.key, x.val, true
+}
+
+// Max finds the largest key in the tree
+func (t *RBTree[K, V]) Max() (k K, v V, found bool) {
+	if t.root == t.NIL {
+		return k, v, false
+	}
+	x := t.maximum(t.root)
+	return x.key, x.val, true
+}
+
+// Floor finds the largest key <= k. Unlike Predecessor, k does not need to be in the tree
+func (t *RBTree[K, V]) Floor(k K) (key K, val V, found bool) {
+	return t.entry(t.floor(k))
+}
+
+// Ceiling finds the smallest key >= k
+func (t *RBTree[K, V]) Ceiling(k K) (key K, val V, found bool) {
+	return t.entry(t.ceiling(k))
+}
+
+// Lower finds the largest key strictly < k
+func (t *RBTree[K, V]) Lower(k K) (key K, val V, found bool) {
+	return t.entry(t.lower(k))
+}
+
+// Higher finds the smallest key strictly > k
+func (t *RBTree[K, V]) Higher(k K) (key K, val V, found bool) {
+	return t.entry(t.higher(k))
+}
+
+func (t *RBTree[K, V]) entry(x *node[K, V]) (k K, v V, found bool) {
+	if x == t.NIL {
+		return k, v, false
+	}
+	return x.key, x.val, true
+}
+
+// floor finds the node with the largest key <= k, or NIL.
+// going right means x is a candidate, going left means it is too large
+func (t *RBTree[K, V]) floor(k K) *node[K, V] {
+	x := t.root
+	y := t.NIL
+	for x != t.NIL {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			y = x
+			x = x.r
+		}
+	}
+	return y
+}
+
+// ceiling finds the node with the smallest key >= k, or NIL
+func (t *RBTree[K, V]) ceiling(k K) *node[K, V] {
+	x := t.root
+	y := t.NIL
+	for x != t.NIL {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x
+		} else if cmp < 0 {
+			y = x
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return y
+}
+
+// lower finds the node with the largest key < k, or NIL
+func (t *RBTree[K, V]) lower(k K) *node[K, V] {
+	x := t.root
+	y := t.NIL
+	for x != t.NIL {
+		if t.compare(k, x.key) <= 0 {
+			x = x.l
+		} else {
+			y = x
+			x = x.r
+		}
+	}
+	return y
+}
+
+// higher finds the node with the smallest key > k, or NIL
+func (t *RBTree[K, V]) higher(k K) *node[K, V] {
+	x := t.root
+	y := t.NIL
+	for x != t.NIL {
+		if t.compare(k, x.key) < 0 {
+			y = x
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return y
+}
+
 func (t *RBTree[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
 	if x != t.NIL {
 		t.traverse(x.l, action)
@@ -403,3 +632,99 @@ func (t *RBTree[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
 		t.traverse(x.r, action)
 	}
 }
+
+// All returns an iterator over every key and value in ascending key order.
+// the walk starts at the minimum and follows successors, so breaking out
+// of the loop stops the traversal early
+func (t *RBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == t.NIL {
+			return
+		}
+		for x := t.minimum(t.root); x != t.NIL; x = t.successor(x) {
+			if !yield(x.key, x.val) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over every key and value in descending key order
+func (t *RBTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == t.NIL {
+			return
+		}
+		for x := t.maximum(t.root); x != t.NIL; x = t.predecessor(x) {
+			if !yield(x.key, x.val) {
+				return
+			}
+		}
+	}
+}
+
+// ValidationError describes a broken invariant and where it was found.
+// Path is the route from the root, e.g. "root/L/R" is the right child of the root's left child
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("rbtree: %s at %s", e.Reason, e.Path)
+}
+
+// Validate checks the red-black properties: the root and NIL are black, a red node has
+// no red children and every path down has the same number of black nodes. it also checks
+// key ordering and parent pointers, and returns the first problem found, or nil
+func (t *RBTree[K, V]) Validate() error {
+	if t.NIL.color != BLACK {
+		return &ValidationError{Path: "NIL", Reason: "sentinel is red"}
+	}
+	if t.root.color != BLACK {
+		return &ValidationError{Path: "root", Reason: "root is red"}
+	}
+	if t.root != t.NIL && t.root.p != t.NIL {
+		return &ValidationError{Path: "root", Reason: "root has a parent"}
+	}
+	_, err := t.validate(t.root, nil, nil, "root")
+	return err
+}
+
+// validate checks the subtree at x, where every key must be strictly between lo and hi
+// when they are not nil, and returns its black height
+func (t *RBTree[K, V]) validate(x *node[K, V], lo, hi *K, path string) (int, error) {
+	if x == t.NIL {
+		return 0, nil
+	}
+	if lo != nil && t.compare(x.key, *lo) <= 0 || hi != nil && t.compare(x.key, *hi) >= 0 {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("key %v is out of order", x.key)}
+	}
+	if x.color != RED && x.color != BLACK {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("unknown color %d", x.color)}
+	}
+	if x.color == RED && (x.l.color == RED || x.r.color == RED) {
+		return 0, &ValidationError{Path: path, Reason: "red node has a red child"}
+	}
+	if x.l != t.NIL && x.l.p != x {
+		return 0, &ValidationError{Path: path + "/L", Reason: "parent pointer does not point back"}
+	}
+	if x.r != t.NIL && x.r.p != x {
+		return 0, &ValidationError{Path: path + "/R", Reason: "parent pointer does not point back"}
+	}
+	l, err := t.validate(x.l, lo, &x.key, path+"/L")
+	if err != nil {
+		return 0, err
+	}
+	r, err := t.validate(x.r, &x.key, hi, path+"/R")
+	if err != nil {
+		return 0, err
+	}
+	if l != r {
+		return 0, &ValidationError{Path: path, Reason: fmt.Sprintf("black height is %d on the left and %d on the right", l, r)}
+	}
+	if x.color == BLACK {
+		l++
+	}
+	return l, nil
+}
@@ -0,0 +1,90 @@
+package rbtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the tree in Graphviz DOT format with red and black filled nodes.
+// NIL children are drawn as points so left and right children can be told apart
+//
+//	dot -Tsvg tree.dot > tree.svg
+func (t *RBTree[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph rbtree {")
+	fmt.Fprintln(bw, "\tnode [style=filled, fontcolor=white, fillcolor=black];")
+	if t.root != t.NIL {
+		id := 0
+		t.writeDOT(bw, t.root, &id)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDOT writes x and its subtree, numbering nodes in pre-order, and returns the id of x
+func (t *RBTree[K, V]) writeDOT(w io.Writer, x *node[K, V], id *int) int {
+	me := *id
+	*id++
+	if x == t.NIL {
+		fmt.Fprintf(w, "\tn%d [shape=point];\n", me)
+		return me
+	}
+	color := "black"
+	if x.color == RED {
+		color = "red"
+	}
+	fmt.Fprintf(w, "\tn%d [label=%s, fillcolor=%s];\n", me, dotQuote(fmt.Sprint(x.key)), color)
+	l := t.writeDOT(w, x.l, id)
+	r := t.writeDOT(w, x.r, id)
+	fmt.Fprintf(w, "\tn%d -> n%d;\n\tn%d -> n%d;\n", me, l, me, r)
+	return me
+}
+
+// dotQuote makes s safe to use as a DOT string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteASCII writes the tree sideways, the root on the left and larger keys above smaller ones.
+// red nodes are marked with (R)
+//
+//	|   /-- 20
+//	/-- 18
+//	|   \-- 17
+//	15
+//	\-- 6
+func (t *RBTree[K, V]) WriteASCII(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if t.root != t.NIL {
+		t.writeASCII(bw, t.root.r, "", true)
+		fmt.Fprintln(bw, t.label(t.root))
+		t.writeASCII(bw, t.root.l, "", false)
+	}
+	return bw.Flush()
+}
+
+// writeASCII writes the subtree at x. upper is true when x is a right child, drawn above its parent
+func (t *RBTree[K, V]) writeASCII(w io.Writer, x *node[K, V], prefix string, upper bool) {
+	if x == t.NIL {
+		return
+	}
+	// the vertical bar continues toward the parent, which is below an upper child and above a lower one
+	above, below := "|   ", "    "
+	branch := "/-- "
+	if !upper {
+		above, below = below, above
+		branch = `\-- `
+	}
+	t.writeASCII(w, x.r, prefix+below, true)
+	fmt.Fprintln(w, prefix+branch+t.label(x))
+	t.writeASCII(w, x.l, prefix+above, false)
+}
+
+func (t *RBTree[K, V]) label(x *node[K, V]) string {
+	if x.color == RED {
+		return fmt.Sprintf("%v(R)", x.key)
+	}
+	return fmt.Sprint(x.key)
+}
@@ -0,0 +1,149 @@
+package rbtree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+var sum = Monoid[int]{Identity: 0, Combine: func(a, b int) int { return a + b }}
+
+// concatenation is not commutative, so it catches aggregates combined out of order
+var concat = Monoid[string]{Identity: "", Combine: func(a, b string) string { return a + b }}
+
+// checkAugmented verifies the coloring and that every aggregate matches its subtree
+func checkAugmented[V comparable](t *testing.T, a *Augmented[int, V]) {
+	t.Helper()
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+	var walk func(x *node[int, aval[V]]) int
+	walk = func(x *node[int, aval[V]]) int {
+		if x == a.t.NIL {
+			return 0
+		}
+		if want := a.m.Combine(a.m.Combine(a.agg(x.l), x.val.val), a.agg(x.r)); x.val.agg != want {
+			t.Errorf("node %d has aggregate %v, expected %v", x.key, x.val.agg, want)
+		}
+		return walk(x.l) + walk(x.r) + 1
+	}
+	if n := walk(a.t.root); n != a.Size() {
+		t.Errorf("size is %d, tree has %d nodes", a.Size(), n)
+	}
+}
+
+func TestAugmented_Aggregate(t *testing.T) {
+	r := rand.New(rand.NewSource(123))
+	a := NewAugmented[int, string](intcmp, concat)
+	m := map[int]string{}
+	for i := 0; i < 1000; i++ {
+		k := r.Intn(500)
+		v := strconv.Itoa(i) + ","
+		a.Insert(k, v)
+		m[k] = v
+	}
+	for i := 0; i < 400; i++ {
+		k := r.Intn(500)
+		_, found := a.Remove(k)
+		if _, ok := m[k]; ok != found {
+			t.Errorf("Remove(%d) found is %v, expected %v", k, found, ok)
+		}
+		delete(m, k)
+	}
+	checkAugmented(t, a)
+	if a.Size() != len(m) {
+		t.Errorf("size is %d, expected %d", a.Size(), len(m))
+	}
+
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for i := 0; i < 500; i++ {
+		lo, hi := r.Intn(520)-10, r.Intn(520)-10
+		expected := ""
+		for _, k := range keys {
+			if k >= lo && k < hi {
+				expected += m[k]
+			}
+		}
+		if got := a.Aggregate(lo, hi); got != expected {
+			t.Errorf("Aggregate(%d, %d) returned %q, expected %q", lo, hi, got, expected)
+		}
+	}
+
+	all := ""
+	for _, k := range keys {
+		all += m[k]
+	}
+	if a.Total() != all {
+		t.Errorf("Total returned %q, expected %q", a.Total(), all)
+	}
+}
+
+func TestAugmented_Replace(t *testing.T) {
+	a := NewAugmented[int, int](intcmp, sum)
+	for i := 0; i < 100; i++ {
+		a.Insert(i, i)
+	}
+	if got := a.Aggregate(10, 20); got != 145 {
+		t.Errorf("Aggregate(10, 20) returned %d, expected 145", got)
+	}
+	old, replaced := a.Insert(15, 1000)
+	if !replaced || old != 15 {
+		t.Errorf("Insert(15) returned (%d, %v), expected (15, true)", old, replaced)
+	}
+	if got := a.Aggregate(10, 20); got != 1130 {
+		t.Errorf("after replacing, Aggregate(10, 20) returned %d, expected 1130", got)
+	}
+	if got := a.Aggregate(20, 10); got != 0 {
+		t.Errorf("empty range returned %d, expected 0", got)
+	}
+	checkAugmented(t, a)
+
+	for i := 0; i < 100; i++ {
+		a.Remove(i)
+	}
+	if a.Total() != 0 || a.Size() != 0 {
+		t.Errorf("emptied tree has total %d and size %d", a.Total(), a.Size())
+	}
+}
+
+func TestAugmented_Scan(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+	least := Monoid[int]{Identity: math.MaxInt, Combine: func(a, b int) int { return min(a, b) }}
+	a := NewAugmented[int, int](intcmp, least)
+	m := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		k, v := r.Intn(2000), r.Intn(1000)
+		a.Insert(k, v)
+		m[k] = v
+	}
+	checkAugmented(t, a)
+
+	var expected []int
+	for k, v := range m {
+		if v < 20 {
+			expected = append(expected, k)
+		}
+	}
+	slices.Sort(expected)
+	var got []int
+	visited := 0
+	for k, v := range a.Scan(func(agg int) bool { return agg < 20 }) {
+		visited++
+		if v < 20 {
+			got = append(got, k)
+		}
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Scan found %v, expected %v", got, expected)
+	}
+	// besides the matches, only their ancestors are yielded
+	if limit := len(expected) * (a.Height() + 1); visited > limit {
+		t.Errorf("Scan yielded %d keys for %d matches", visited, len(expected))
+	}
+}
@@ -0,0 +1,123 @@
+package rbtree
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCursor_Walk(t *testing.T) {
+	b := New[int, int](intcmp)
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	c := b.Cursor()
+	if c.Valid() {
+		t.Errorf("a new cursor should not be on an entry")
+	}
+
+	var keys []int
+	for ok := c.First(); ok; ok = c.Next() {
+		if c.Value() != c.Key()*10 {
+			t.Errorf("value for %d is %d", c.Key(), c.Value())
+		}
+		keys = append(keys, c.Key())
+	}
+	if !slices.Equal(keys, keySlice(b)) {
+		t.Errorf("forward walk got %v", keys)
+	}
+	if c.Next() || c.Valid() {
+		t.Errorf("cursor should stay off the end")
+	}
+
+	keys = nil
+	for ok := c.Last(); ok; ok = c.Prev() {
+		keys = append(keys, c.Key())
+	}
+	want := keySlice(b)
+	slices.Reverse(want)
+	if !slices.Equal(keys, want) {
+		t.Errorf("backward walk got %v", keys)
+	}
+}
+
+func TestCursor_Seek(t *testing.T) {
+	b := New[int, int](intcmp)
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+	c := b.Cursor()
+
+	if !c.Seek(13) || c.Key() != 13 {
+		t.Errorf("Seek(13) should land on 13, got %d", c.Key())
+	}
+	if !c.Seek(14) || c.Key() != 15 {
+		t.Errorf("Seek(14) should land on 15, got %d", c.Key())
+	}
+	if !c.Prev() || c.Key() != 13 {
+		t.Errorf("Prev from 15 should be 13, got %d", c.Key())
+	}
+	if c.Seek(21) {
+		t.Errorf("Seek past the maximum should not be valid")
+	}
+
+	c.Seek(7)
+	c.SetValue(700)
+	if v, _ := b.Search(7); v != 700 {
+		t.Errorf("SetValue should change the tree, got %d", v)
+	}
+
+	empty := New[int, int](intcmp).Cursor()
+	if empty.First() || empty.Last() || empty.Seek(1) {
+		t.Errorf("cursor on an empty tree should never be valid")
+	}
+	if empty.Key() != 0 || empty.Value() != 0 || empty.Delete() {
+		t.Errorf("invalid cursor should return zero values")
+	}
+}
+
+func TestCursor_Delete(t *testing.T) {
+	b := New[int, int](intcmp)
+	for i := 0; i < 200; i++ {
+		b.Insert(i, i)
+	}
+
+	// delete every odd key while walking forward
+	c := b.Cursor()
+	for ok := c.First(); ok; {
+		if c.Key()%2 == 1 {
+			ok = c.Delete()
+		} else {
+			ok = c.Next()
+		}
+	}
+
+	keys := keySlice(b)
+	if len(keys) != 100 {
+		t.Errorf("expected 100 keys left, got %d", len(keys))
+	}
+	for _, k := range keys {
+		if k%2 == 1 {
+			t.Errorf("odd key %d was not deleted", k)
+		}
+	}
+	if err := b.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// deleting the last entry walks off the end
+	c.Last()
+	if c.Delete() {
+		t.Errorf("deleting the maximum should leave the cursor invalid")
+	}
+	if b.ContainsKey(198) {
+		t.Errorf("198 was not deleted")
+	}
+
+	// delete everything
+	for ok := c.First(); ok; ok = c.Delete() {
+	}
+	if b.Size() != 0 {
+		t.Errorf("size %d, want 0", b.Size())
+	}
+}
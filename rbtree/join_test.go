@@ -0,0 +1,281 @@
+package rbtree
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func BenchmarkRBTree_Split(b *testing.B) {
+	r := rand.New(rand.NewSource(123))
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tree := New[int, int](intcmp)
+		for z := 0; z < 10000; z++ {
+			tree.Insert(z, z)
+		}
+		k := r.Intn(10000)
+		b.StartTimer()
+
+		l, rt := tree.Split(k)
+		Join(l, -1, -1, New[int, int](intcmp))
+		_ = rt
+	}
+}
+
+func treeOf(keys ...int) *RBTree[int, int] {
+	t := New[int, int](intcmp)
+	for _, k := range keys {
+		t.Insert(k, k)
+	}
+	return t
+}
+
+func TestRBTree_Split(t *testing.T) {
+	for _, k := range []int{-1, 0, 1, 25, 50, 51, 99, 100, 150} {
+		tree := New[int, int](intcmp)
+		for i := 0; i < 100; i += 2 {
+			tree.Insert(i, i)
+		}
+
+		l, r := tree.Split(k)
+		checkRB(t, l)
+		checkRB(t, r)
+
+		for _, key := range keySlice(l) {
+			if key >= k {
+				t.Errorf("split at %d, left has %d", k, key)
+			}
+		}
+		for _, key := range keySlice(r) {
+			if key < k {
+				t.Errorf("split at %d, right has %d", k, key)
+			}
+		}
+		if l.Size()+r.Size() != 50 {
+			t.Errorf("split at %d lost keys, %d + %d", k, l.Size(), r.Size())
+		}
+		if tree.Size() != 0 {
+			t.Errorf("split should leave the tree empty")
+		}
+	}
+}
+
+func TestRBTree_Join(t *testing.T) {
+	// uneven heights on both sides, from trees built separately
+	for _, sizes := range [][2]int{{0, 0}, {0, 10}, {10, 0}, {1, 500}, {500, 1}, {100, 100}} {
+		l := New[int, int](intcmp)
+		r := New[int, int](intcmp)
+		for i := 0; i < sizes[0]; i++ {
+			l.Insert(i, i)
+		}
+		for i := 0; i < sizes[1]; i++ {
+			r.Insert(1000+i, 1000+i)
+		}
+
+		j := Join(l, 999, 999, r)
+		checkRB(t, j)
+
+		keys := keySlice(j)
+		if len(keys) != sizes[0]+sizes[1]+1 || !slices.IsSorted(keys) {
+			t.Errorf("join of %v is wrong, got %d keys", sizes, len(keys))
+		}
+		if v, _ := j.Search(999); v != 999 {
+			t.Errorf("join key is missing")
+		}
+		if l.Size() != 0 || r.Size() != 0 {
+			t.Errorf("join should leave the inputs empty")
+		}
+
+		// the result should still work as a normal tree
+		j.Insert(-5, -5)
+		j.Remove(999)
+		checkRB(t, j)
+	}
+}
+
+// joining two trees built separately should only touch the spine it links into and the
+// nodes rebalanced above it, never the leaves of either tree
+func TestRBTree_JoinCost(t *testing.T) {
+	const n = 1 << 12
+	l := New[int, int](intcmp)
+	r := New[int, int](intcmp)
+	for i := 0; i < n; i++ {
+		l.Insert(i, i)
+		r.Insert(n+1+i, i)
+	}
+	type links struct{ l, r, p *node[int, int] }
+	before := map[*node[int, int]]links{}
+	for _, tree := range []*RBTree[int, int]{l, r} {
+		tree.traverse(tree.root, func(x *node[int, int]) {
+			before[x] = links{x.l, x.r, x.p}
+		})
+	}
+
+	j := Join(l, n, n, r)
+	checkRB(t, j)
+
+	changed := 0
+	for x, was := range before {
+		if (links{x.l, x.r, x.p}) != was {
+			changed++
+		}
+	}
+	// a spine of each tree, plus a constant number of rotations
+	if limit := 4 * bits.Len(n); changed > limit {
+		t.Errorf("join changed the links of %d nodes, expected at most %d", changed, limit)
+	}
+}
+
+// split, join and the set operations carry black heights instead of counting them, so
+// check every height they hand back against a count from the tree itself
+func TestRBTree_BlackHeights(t *testing.T) {
+	r := rand.New(rand.NewSource(123))
+	check := func(op string, s subtree[int, int], tree *RBTree[int, int]) {
+		t.Helper()
+		if want := tree.subtree(s.x).h; s.h != want {
+			t.Errorf("%s returned black height %d, the tree has %d", op, s.h, want)
+		}
+	}
+	random := func(n int) *RBTree[int, int] {
+		tree := New[int, int](intcmp)
+		for i := 0; i < n; i++ {
+			tree.Insert(r.Intn(4*n), i)
+		}
+		return tree
+	}
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		for i := 0; i < 20; i++ {
+			tree := random(n)
+			l, m, rt := tree.split(tree.subtree(tree.root), r.Intn(4*n+1))
+			check("split", l, tree)
+			check("split", rt, tree)
+			if m != tree.NIL {
+				check("join", tree.join(l, m, rt), tree)
+			} else {
+				check("join2", tree.join2(l, rt), tree)
+			}
+
+			a, b := random(n), random(n/2+1)
+			check("union", a.union(a.subtree(a.root), a.subtree(b.root)), a)
+			a, b = random(n), random(n/2+1)
+			check("intersection", a.intersection(a.subtree(a.root), a.subtree(b.root)), a)
+			a, b = random(n), random(n/2+1)
+			check("difference", a.difference(a.subtree(a.root), a.subtree(b.root)), a)
+		}
+	}
+}
+
+// the halves of a split share a sentinel with each other and every other tree, which is
+// only safe because nothing writes to it. run with -race
+func TestRBTree_SplitConcurrent(t *testing.T) {
+	tree := New[int, int](intcmp)
+	for i := 0; i < 2000; i++ {
+		tree.Insert(i, i)
+	}
+	l, r := tree.Split(1000)
+	var wg sync.WaitGroup
+	for _, half := range []*RBTree[int, int]{l, r} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(123))
+			for i := 0; i < 2000; i++ {
+				k := rnd.Intn(2000)
+				half.Remove(k)
+				half.Insert(k+2000*rnd.Intn(2), i)
+			}
+		}()
+	}
+	wg.Wait()
+	checkRB(t, l)
+	checkRB(t, r)
+}
+
+func TestRBTree_JoinOutOfOrder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("joining out of order keys should panic")
+		}
+	}()
+	Join(treeOf(1, 2, 3), 2, 2, treeOf(4))
+}
+
+func TestRBTree_SetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+
+	for round := 0; round < 20; round++ {
+		var as, bs []int
+		for i := r.Intn(200); i > 0; i-- {
+			as = append(as, r.Intn(300))
+		}
+		for i := r.Intn(200); i > 0; i-- {
+			bs = append(bs, r.Intn(300))
+		}
+		inA := map[int]bool{}
+		inB := map[int]bool{}
+		for _, k := range as {
+			inA[k] = true
+		}
+		for _, k := range bs {
+			inB[k] = true
+		}
+
+		var union, inter, diff []int
+		for k := 0; k < 300; k++ {
+			if inA[k] || inB[k] {
+				union = append(union, k)
+			}
+			if inA[k] && inB[k] {
+				inter = append(inter, k)
+			}
+			if inA[k] && !inB[k] {
+				diff = append(diff, k)
+			}
+		}
+
+		u := Union(treeOf(as...), treeOf(bs...))
+		checkRB(t, u)
+		if got := keySlice(u); !slices.Equal(got, union) {
+			t.Errorf("union got %v, want %v", got, union)
+		}
+
+		i := Intersection(treeOf(as...), treeOf(bs...))
+		checkRB(t, i)
+		if got := keySlice(i); !slices.Equal(got, inter) {
+			t.Errorf("intersection got %v, want %v", got, inter)
+		}
+
+		d := Difference(treeOf(as...), treeOf(bs...))
+		checkRB(t, d)
+		if got := keySlice(d); !slices.Equal(got, diff) {
+			t.Errorf("difference got %v, want %v", got, diff)
+		}
+	}
+}
+
+func TestRBTree_UnionKeepsFirstValue(t *testing.T) {
+	a := New[int, string](intcmp)
+	b := New[int, string](intcmp)
+	a.Insert(1, "a")
+	b.Insert(1, "b")
+	b.Insert(2, "b")
+
+	u := Union(a, b)
+	if v, _ := u.Search(1); v != "a" {
+		t.Errorf("union should keep the value from a, got %s", v)
+	}
+	if v, _ := u.Search(2); v != "b" {
+		t.Errorf("union should add keys only in b, got %s", v)
+	}
+}
+
+// checkRB fails the test if the tree is not a valid red-black tree
+func checkRB[K any, V any](t *testing.T, b *RBTree[K, V]) {
+	t.Helper()
+	if err := b.Validate(); err != nil {
+		t.Error(err)
+	}
+}
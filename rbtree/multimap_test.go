@@ -0,0 +1,90 @@
+package rbtree
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultiMap_Insert(t *testing.T) {
+	m := NewMultiMap[int, string](intcmp)
+
+	m.Insert(10, "a")
+	m.Insert(5, "b")
+	m.Insert(10, "c")
+	m.Insert(10, "d")
+
+	if got := m.GetAll(10); !slices.Equal(got, []string{"a", "c", "d"}) {
+		t.Errorf("values should be in insertion order, got %v", got)
+	}
+	if m.Count(10) != 3 || m.Count(5) != 1 || m.Count(7) != 0 {
+		t.Errorf("counts %d %d %d, want 3 1 0", m.Count(10), m.Count(5), m.Count(7))
+	}
+	if m.Size() != 4 {
+		t.Errorf("size %d, want 4", m.Size())
+	}
+	if m.GetAll(7) != nil {
+		t.Errorf("missing key should return nil")
+	}
+
+	// the returned slice is a copy
+	got := m.GetAll(10)
+	got[0] = "z"
+	if m.GetAll(10)[0] != "a" {
+		t.Errorf("GetAll should not expose the stored slice")
+	}
+}
+
+func TestMultiMap_RemoveOne(t *testing.T) {
+	m := NewMultiMap[int, string](intcmp)
+
+	m.Insert(10, "a")
+	m.Insert(10, "b")
+	m.Insert(10, "a")
+
+	v, found := m.RemoveOne(10, func(v string) bool { return v == "a" })
+	if !found || v != "a" {
+		t.Errorf("RemoveOne got %s %t", v, found)
+	}
+	if got := m.GetAll(10); !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("only the first match should be removed, got %v", got)
+	}
+
+	if _, found := m.RemoveOne(10, func(v string) bool { return v == "x" }); found {
+		t.Errorf("no value matches x")
+	}
+	if _, found := m.RemoveOne(11, func(string) bool { return true }); found {
+		t.Errorf("missing key should not be found")
+	}
+
+	m.RemoveOne(10, func(string) bool { return true })
+	m.RemoveOne(10, func(string) bool { return true })
+	if m.ContainsKey(10) || m.Size() != 0 {
+		t.Errorf("removing the last value should remove the key")
+	}
+}
+
+func TestMultiMap_RemoveAll(t *testing.T) {
+	m := NewMultiMap[int, int](intcmp)
+
+	for i := 0; i < 1000; i++ {
+		m.Insert(i%10, i)
+	}
+
+	if m.Height() > 8 {
+		t.Errorf("tree should only hold 10 keys, height %d", m.Height())
+	}
+
+	vals, found := m.RemoveAll(3)
+	if !found || len(vals) != 100 || vals[0] != 3 || vals[99] != 993 {
+		t.Errorf("RemoveAll returned %d values", len(vals))
+	}
+	if m.Size() != 900 || m.Count(3) != 0 {
+		t.Errorf("size %d after RemoveAll, want 900", m.Size())
+	}
+	if _, found := m.RemoveAll(3); found {
+		t.Errorf("second RemoveAll should not find the key")
+	}
+	if err := m.tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,148 @@
+package rbtree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func BenchmarkPersistent_Insert(b *testing.B) {
+
+	tree := NewPersistent[int, int](func(a int, b int) int {
+		return a - b
+	})
+	r := rand.New(rand.NewSource(123))
+
+	for i := 0; i < b.N; i++ {
+		obj := r.Int()
+		tree = tree.Insert(obj, obj)
+	}
+}
+
+func TestPersistent_Insert(t *testing.T) {
+	p := NewPersistent[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		p = p.Insert(k, k)
+		checkPersistent(t, p)
+	}
+
+	var keys []int
+	for k := range p.All() {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{2, 3, 4, 6, 7, 9, 13, 15, 17, 18, 20}) {
+		t.Errorf("keys out of order, got %v", keys)
+	}
+
+	if p.Size() != 11 {
+		t.Errorf("size %d, want 11", p.Size())
+	}
+
+	p = p.Insert(7, 70)
+	if got, _ := p.Search(7); got != 70 {
+		t.Errorf("duplicate insert should replace, got %d", got)
+	}
+	if p.Size() != 11 {
+		t.Errorf("duplicate insert changed the size, got %d", p.Size())
+	}
+}
+
+func TestPersistent_Versions(t *testing.T) {
+	v0 := NewPersistent[int, int](intcmp)
+	v1 := v0.Insert(1, 1).Insert(2, 2).Insert(3, 3)
+	v2 := v1.Insert(4, 4).Insert(2, 20)
+	v3 := v2.Remove(1)
+
+	if v0.Size() != 0 || v1.Size() != 3 || v2.Size() != 4 || v3.Size() != 3 {
+		t.Errorf("sizes %d %d %d %d, want 0 3 4 3", v0.Size(), v1.Size(), v2.Size(), v3.Size())
+	}
+
+	if got, _ := v1.Search(2); got != 2 {
+		t.Errorf("v1 should still see the old value, got %d", got)
+	}
+	if got, _ := v2.Search(2); got != 20 {
+		t.Errorf("v2 should see the new value, got %d", got)
+	}
+	if v1.ContainsKey(4) {
+		t.Errorf("v1 should not see a key inserted into v2")
+	}
+	if !v2.ContainsKey(1) || v3.ContainsKey(1) {
+		t.Errorf("removing from v2 should only affect v3")
+	}
+
+	if v3.Remove(100) != v3 {
+		t.Errorf("removing a missing key should return the same tree")
+	}
+}
+
+func TestPersistent_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+	p := NewPersistent[int, int](intcmp)
+
+	// every version is kept along with the map it should match
+	var versions []*Persistent[int, int]
+	var expected []map[int]int
+	m := map[int]int{}
+
+	for i := 0; i < 1000; i++ {
+		k := r.Intn(200)
+		if r.Intn(3) == 0 {
+			p = p.Remove(k)
+			delete(m, k)
+		} else {
+			p = p.Insert(k, i)
+			m[k] = i
+		}
+		c := make(map[int]int, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		versions = append(versions, p)
+		expected = append(expected, c)
+	}
+
+	for i, v := range versions {
+		if v.Size() != len(expected[i]) {
+			t.Fatalf("version %d size %d, want %d", i, v.Size(), len(expected[i]))
+		}
+		for k, val := range v.All() {
+			if want, ok := expected[i][k]; !ok || want != val {
+				t.Fatalf("version %d has %d=%d, want %d", i, k, val, want)
+			}
+		}
+		if i%50 == 0 {
+			checkPersistent(t, v)
+		}
+	}
+}
+
+// checkPersistent checks ordering, no red-red links and equal black height on every path
+func checkPersistent[K any, V any](t *testing.T, p *Persistent[K, V]) {
+	t.Helper()
+	if isRed(p.root) {
+		t.Errorf("root is red")
+	}
+	var check func(x *pnode[K, V]) int
+	check = func(x *pnode[K, V]) int {
+		if x == nil {
+			return 1
+		}
+		if x.l != nil && p.compare(x.l.key, x.key) >= 0 || x.r != nil && p.compare(x.r.key, x.key) <= 0 {
+			t.Errorf("keys out of order at %v", x.key)
+		}
+		if isRed(x) && (isRed(x.l) || isRed(x.r)) {
+			t.Errorf("red node %v has a red child", x.key)
+		}
+		l := check(x.l)
+		r := check(x.r)
+		if l != r {
+			t.Errorf("black height differs at %v, %d and %d", x.key, l, r)
+		}
+		if x.color == BLACK {
+			l++
+		}
+		return l
+	}
+	check(p.root)
+}
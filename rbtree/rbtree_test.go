@@ -1,6 +1,7 @@
 package rbtree
 
 import (
+	"errors"
 	"math/rand"
 	"slices"
 	"strings"
@@ -372,3 +373,212 @@ func keySlice[K any, V any](b *RBTree[K, V]) []K {
 	})
 	return x
 }
+
+func TestRBTree_SuccessorMissingKey(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	b.Insert(15, 15)
+	b.Insert(6, 6)
+
+	if _, found := b.Successor(10); found {
+		t.Errorf("successor of a missing key should not be found")
+	}
+	if _, found := b.Predecessor(10); found {
+		t.Errorf("predecessor of a missing key should not be found")
+	}
+}
+
+func TestRBTree_Navigable(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	type query func(int) (int, int, bool)
+	tests := []struct {
+		name  string
+		q     query
+		k     int
+		want  int
+		found bool
+	}{
+		{"Floor", b.Floor, 13, 13, true},
+		{"Floor", b.Floor, 14, 13, true},
+		{"Floor", b.Floor, 1, 0, false},
+		{"Floor", b.Floor, 25, 20, true},
+		{"Ceiling", b.Ceiling, 13, 13, true},
+		{"Ceiling", b.Ceiling, 14, 15, true},
+		{"Ceiling", b.Ceiling, 21, 0, false},
+		{"Ceiling", b.Ceiling, 0, 2, true},
+		{"Lower", b.Lower, 13, 9, true},
+		{"Lower", b.Lower, 14, 13, true},
+		{"Lower", b.Lower, 2, 0, false},
+		{"Higher", b.Higher, 13, 15, true},
+		{"Higher", b.Higher, 16, 17, true},
+		{"Higher", b.Higher, 20, 0, false},
+	}
+	for _, tt := range tests {
+		k, v, found := tt.q(tt.k)
+		if found != tt.found {
+			t.Errorf("%s(%d) found %t, want %t", tt.name, tt.k, found, tt.found)
+			continue
+		}
+		if found && (k != tt.want || v != tt.want*10) {
+			t.Errorf("%s(%d) got %d %d, want %d", tt.name, tt.k, k, v, tt.want)
+		}
+	}
+
+	if k, _, _ := b.Min(); k != 2 {
+		t.Errorf("Min got %d, want 2", k)
+	}
+	if k, _, _ := b.Max(); k != 20 {
+		t.Errorf("Max got %d, want 20", k)
+	}
+
+	empty := New[int, int](intcmp)
+	if _, _, found := empty.Min(); found {
+		t.Errorf("empty tree has no minimum")
+	}
+	if _, _, found := empty.Max(); found {
+		t.Errorf("empty tree has no maximum")
+	}
+	if _, _, found := empty.Floor(3); found {
+		t.Errorf("empty tree has no floor")
+	}
+}
+
+func TestRBTree_Validate(t *testing.T) {
+	b := New[int, int](intcmp)
+	r := rand.New(rand.NewSource(321))
+
+	if err := b.Validate(); err != nil {
+		t.Errorf("empty tree should be valid, got %v", err)
+	}
+
+	for i := 0; i < 500; i++ {
+		b.Insert(r.Intn(200), i)
+		if i%3 == 0 {
+			b.Remove(r.Intn(200))
+		}
+		if err := b.Validate(); err != nil {
+			t.Fatalf("valid tree reported %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func()
+		path    string
+	}{
+		{"red root", func() { b.root.color = RED }, "root"},
+		{"red sentinel", func() { b.NIL.color = RED }, "NIL"},
+		{"black height", func() { b.root.l.color = RED }, "root"},
+		{"order", func() { b.root.r.key = 0 }, "root/R"},
+		{"parent", func() { b.root.r.p = b.root.l }, "root/R"},
+		{"red red", func() {
+			b.root.r.color = RED
+			b.root.r.r.color = RED
+		}, "root/R"},
+	}
+	for _, tt := range tests {
+		// rebuild so every case starts from a valid tree. New would share NIL with every
+		// other tree of these types, so the tree gets a sentinel of its own to corrupt
+		b = &RBTree[int, int]{compare: intcmp, NIL: &node[int, int]{color: BLACK}}
+		b.root = b.NIL
+		for i := 1; i <= 15; i++ {
+			b.Insert(i, i)
+		}
+		tt.corrupt()
+
+		err := b.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a ValidationError, got %v", tt.name, err)
+			continue
+		}
+		if verr.Path != tt.path {
+			t.Errorf("%s: expected the error at %s, got %v", tt.name, tt.path, verr)
+		}
+	}
+}
+
+func TestRBTree_All(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k*10)
+	}
+
+	var keys []int
+	for k, v := range b.All() {
+		if v != k*10 {
+			t.Errorf("value for %d should be %d, got %d", k, k*10, v)
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, keySlice(b)) {
+		t.Errorf("All did not match in order traversal, got %v", keys)
+	}
+
+	keys = nil
+	for k := range b.Backward() {
+		if k < 17 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{20, 18, 17}) {
+		t.Errorf("Backward should stop at 17, got %v", keys)
+	}
+
+	for k := range New[int, int](intcmp).All() {
+		t.Errorf("empty tree should not yield, got %d", k)
+	}
+}
+
+func TestRBTree_FromSorted(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 4, 7, 8, 100, 1023, 1024} {
+		b, err := FromSorted(intcmp, func(yield func(int, int) bool) {
+			for i := 0; i < n; i++ {
+				if !yield(i*2, i) {
+					return
+				}
+			}
+		})
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("n=%d: %v", n, err)
+		}
+		if b.Size() != n {
+			t.Errorf("n=%d: size is %d", n, b.Size())
+		}
+		i := 0
+		for k, v := range b.All() {
+			if k != i*2 || v != i {
+				t.Errorf("n=%d: entry %d is (%d, %d)", n, i, k, v)
+			}
+			i++
+		}
+		for i := 0; i < n; i += 3 {
+			b.Remove(i * 2)
+		}
+		b.Insert(-1, -1)
+		if err := b.Validate(); err != nil {
+			t.Errorf("n=%d: after modifying, %v", n, err)
+		}
+	}
+
+	_, err := FromSorted(intcmp, func(yield func(int, int) bool) {
+		for _, k := range []int{1, 5, 5} {
+			if !yield(k, k) {
+				return
+			}
+		}
+	})
+	if !errors.Is(err, ErrUnsorted) {
+		t.Errorf("expected ErrUnsorted, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package rbtree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRBTree_WriteASCII(t *testing.T) {
+	b := New[int, int](intcmp)
+	for i := 1; i <= 5; i++ {
+		b.Insert(i, i)
+	}
+
+	var sb strings.Builder
+	if err := b.WriteASCII(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `    /-- 5(R)
+/-- 4
+|   \-- 3(R)
+2
+\-- 1
+`
+	if sb.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	sb.Reset()
+	New[int, int](intcmp).WriteASCII(&sb)
+	if sb.String() != "" {
+		t.Errorf("empty tree should write nothing, got %q", sb.String())
+	}
+}
+
+func TestRBTree_WriteDOT(t *testing.T) {
+	b := New[int, int](intcmp)
+	b.Insert(2, 2)
+	b.Insert(1, 1)
+	b.Insert(3, 3)
+	b.Insert(4, 4)
+
+	var sb strings.Builder
+	if err := b.WriteDOT(&sb); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+
+	if !strings.HasPrefix(got, "digraph rbtree {\n") || !strings.HasSuffix(got, "}\n") {
+		t.Errorf("not a digraph:\n%s", got)
+	}
+	for _, want := range []string{
+		`n0 [label="2", fillcolor=black];`,
+		`n6 [label="4", fillcolor=red];`,
+		`n0 -> n1;`,
+		`n0 -> n4;`,
+		`[shape=point]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+
+	s := New[string, int](strings.Compare)
+	s.Insert(`say "hi"`, 1)
+	sb.Reset()
+	s.WriteDOT(&sb)
+	if !strings.Contains(sb.String(), `label="say \"hi\""`) {
+		t.Errorf("quotes should be escaped, got\n%s", sb.String())
+	}
+}
@@ -0,0 +1,215 @@
+package sortedset
+
+import (
+	"iter"
+	"math/bits"
+
+	"github.com/a-tk/go-datastructures/rbtree"
+)
+
+// Set is an ordered set backed by a red-black tree. the set operations return new
+// sets and leave both inputs unchanged
+type Set[K any] struct {
+	tree    *rbtree.RBTree[K, struct{}]
+	size    int
+	compare func(K, K) int
+}
+
+func New[K any](compare func(K, K) int) *Set[K] {
+	return &Set[K]{
+		tree:    rbtree.New[K, struct{}](compare),
+		size:    0,
+		compare: compare,
+	}
+}
+
+// Add inserts k, returning false if it was already in the set
+func (s *Set[K]) Add(k K) bool {
+	_, replaced := s.tree.Insert(k, struct{}{})
+	if !replaced {
+		s.size++
+	}
+	return !replaced
+}
+
+// Remove deletes k, returning false if it was not in the set
+func (s *Set[K]) Remove(k K) bool {
+	_, found := s.tree.Remove(k)
+	if found {
+		s.size--
+	}
+	return found
+}
+
+func (s *Set[K]) Contains(k K) bool {
+	return s.tree.ContainsKey(k)
+}
+
+// Size is O(1), unlike the tree's Size
+func (s *Set[K]) Size() int {
+	return s.size
+}
+
+// All returns an iterator over the set in ascending order
+func (s *Set[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.tree.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the set in descending order
+func (s *Set[K]) Backward() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.tree.Backward() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Union holds every key in s or o, always a linear merge since every key is copied
+func (s *Set[K]) Union(o *Set[K]) *Set[K] {
+	keys := make([]K, 0, s.size+o.size)
+	s.merge(o, func(k K, inS, inO bool) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return s.build(keys)
+}
+
+// Intersection holds the keys in both s and o. when one side is much smaller,
+// its keys are looked up in the other instead of walking both
+func (s *Set[K]) Intersection(o *Set[K]) *Set[K] {
+	var keys []K
+	small, large := s, o
+	if small.size > large.size {
+		small, large = large, small
+	}
+	if lookups(small.size, large.size) {
+		for k := range small.All() {
+			if large.Contains(k) {
+				keys = append(keys, k)
+			}
+		}
+		return s.build(keys)
+	}
+	s.merge(o, func(k K, inS, inO bool) bool {
+		if inS && inO {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return s.build(keys)
+}
+
+// Difference holds the keys in s that are not in o
+func (s *Set[K]) Difference(o *Set[K]) *Set[K] {
+	var keys []K
+	if lookups(s.size, o.size) {
+		for k := range s.All() {
+			if !o.Contains(k) {
+				keys = append(keys, k)
+			}
+		}
+		return s.build(keys)
+	}
+	s.merge(o, func(k K, inS, inO bool) bool {
+		if inS && !inO {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return s.build(keys)
+}
+
+// SymmetricDifference holds the keys in exactly one of s and o
+func (s *Set[K]) SymmetricDifference(o *Set[K]) *Set[K] {
+	var keys []K
+	s.merge(o, func(k K, inS, inO bool) bool {
+		if inS != inO {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return s.build(keys)
+}
+
+// IsSubset reports whether every key in s is also in o. it stops at the first key
+// of s that o is missing
+func (s *Set[K]) IsSubset(o *Set[K]) bool {
+	if s.size > o.size {
+		return false
+	}
+	if lookups(s.size, o.size) {
+		for k := range s.All() {
+			if !o.Contains(k) {
+				return false
+			}
+		}
+		return true
+	}
+	subset := true
+	s.merge(o, func(k K, inS, inO bool) bool {
+		subset = !inS || inO
+		return subset
+	})
+	return subset
+}
+
+// IsSuperset reports whether every key in o is also in s
+func (s *Set[K]) IsSuperset(o *Set[K]) bool {
+	return o.IsSubset(s)
+}
+
+// lookups decides between searching the large set once per key of the small set,
+// O(small log large), and walking both sets side by side, O(small + large)
+func lookups(small, large int) bool {
+	return small*bits.Len(uint(large)) < small+large
+}
+
+// build makes a set from keys that are already strictly increasing in O(n),
+// instead of adding them one at a time in O(n log n)
+func (s *Set[K]) build(keys []K) *Set[K] {
+	tree, err := rbtree.FromSorted(s.compare, func(yield func(K, struct{}) bool) {
+		for _, k := range keys {
+			if !yield(k, struct{}{}) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		// every caller collects keys in ascending order, so this is a bug here
+		panic(err)
+	}
+	return &Set[K]{tree: tree, size: len(keys), compare: s.compare}
+}
+
+// merge walks s and o together in ascending order with a cursor on each, calling emit
+// once per distinct key with where it was found. it stops as soon as emit returns false,
+// without visiting the rest of either set
+func (s *Set[K]) merge(o *Set[K], emit func(k K, inS, inO bool) bool) {
+	a, b := s.tree.Cursor(), o.tree.Cursor()
+	inA, inB := a.First(), b.First()
+	for inA || inB {
+		var cont bool
+		switch {
+		case !inB || inA && s.compare(a.Key(), b.Key()) < 0:
+			cont = emit(a.Key(), true, false)
+			inA = a.Next()
+		case !inA || s.compare(a.Key(), b.Key()) > 0:
+			cont = emit(b.Key(), false, true)
+			inB = b.Next()
+		default:
+			cont = emit(a.Key(), true, true)
+			inA, inB = a.Next(), b.Next()
+		}
+		if !cont {
+			return
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package sortedset
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+func setOf(keys ...int) *Set[int] {
+	s := New[int](intcmp)
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+func BenchmarkSet_IntersectionLarge(b *testing.B) {
+	r := rand.New(rand.NewSource(123))
+	x := New[int](intcmp)
+	y := New[int](intcmp)
+	for i := 0; i < 10000; i++ {
+		x.Add(r.Intn(20000))
+		y.Add(r.Intn(20000))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Intersection(y)
+	}
+}
+
+func BenchmarkSet_IntersectionSmall(b *testing.B) {
+	r := rand.New(rand.NewSource(123))
+	x := New[int](intcmp)
+	y := New[int](intcmp)
+	for i := 0; i < 10; i++ {
+		x.Add(r.Intn(20000))
+	}
+	for i := 0; i < 10000; i++ {
+		y.Add(r.Intn(20000))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Intersection(y)
+	}
+}
+
+func TestSet_Add(t *testing.T) {
+	s := New[int](intcmp)
+
+	if !s.Add(5) || !s.Add(3) || s.Add(5) {
+		t.Errorf("Add should only report new keys")
+	}
+	if s.Size() != 2 {
+		t.Errorf("size %d, want 2", s.Size())
+	}
+	if !s.Contains(3) || s.Contains(4) {
+		t.Errorf("Contains incorrect")
+	}
+	if !s.Remove(3) || s.Remove(3) {
+		t.Errorf("Remove should only report present keys")
+	}
+	if s.Size() != 1 {
+		t.Errorf("size %d, want 1", s.Size())
+	}
+}
+
+func TestSet_All(t *testing.T) {
+	s := setOf(5, 1, 4, 2, 3)
+
+	if got := slices.Collect(s.All()); !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("All got %v", got)
+	}
+	if got := slices.Collect(s.Backward()); !slices.Equal(got, []int{5, 4, 3, 2, 1}) {
+		t.Errorf("Backward got %v", got)
+	}
+}
+
+func TestSet_Operations(t *testing.T) {
+	r := rand.New(rand.NewSource(321))
+
+	// sizes cover both the merge and the lookup strategies
+	for _, sizes := range [][2]int{{0, 0}, {0, 50}, {3, 500}, {500, 3}, {200, 200}, {1, 1}} {
+		var as, bs []int
+		for i := 0; i < sizes[0]; i++ {
+			as = append(as, r.Intn(1000))
+		}
+		for i := 0; i < sizes[1]; i++ {
+			bs = append(bs, r.Intn(1000))
+		}
+		a := setOf(as...)
+		b := setOf(bs...)
+
+		var union, inter, diff, sym []int
+		for k := 0; k < 1000; k++ {
+			inA, inB := a.Contains(k), b.Contains(k)
+			if inA || inB {
+				union = append(union, k)
+			}
+			if inA && inB {
+				inter = append(inter, k)
+			}
+			if inA && !inB {
+				diff = append(diff, k)
+			}
+			if inA != inB {
+				sym = append(sym, k)
+			}
+		}
+
+		check := func(name string, got *Set[int], want []int) {
+			t.Helper()
+			keys := slices.Collect(got.All())
+			if !slices.Equal(keys, want) || got.Size() != len(want) {
+				t.Errorf("%v %s got %v, want %v", sizes, name, keys, want)
+			}
+			if err := got.tree.Validate(); err != nil {
+				t.Errorf("%v %s built an invalid tree: %v", sizes, name, err)
+			}
+			// the result is a normal set, it can still be changed
+			got.Add(-1)
+			got.Remove(-1)
+			if err := got.tree.Validate(); err != nil {
+				t.Errorf("%v %s is invalid after Add and Remove: %v", sizes, name, err)
+			}
+		}
+		check("union", a.Union(b), union)
+		check("intersection", a.Intersection(b), inter)
+		check("difference", a.Difference(b), diff)
+		check("symmetric difference", a.SymmetricDifference(b), sym)
+
+		if a.Size() != len(slices.Collect(a.All())) || b.Size() != len(slices.Collect(b.All())) {
+			t.Errorf("set operations should not change their inputs")
+		}
+	}
+}
+
+func TestSet_IsSubset(t *testing.T) {
+	big := New[int](intcmp)
+	for i := 0; i < 1000; i++ {
+		big.Add(i)
+	}
+
+	tests := []struct {
+		name string
+		s, o *Set[int]
+		want bool
+	}{
+		{"empty", setOf(), setOf(1), true},
+		{"equal", setOf(1, 2, 3), setOf(1, 2, 3), true},
+		{"proper", setOf(1, 3), setOf(1, 2, 3), true},
+		{"missing", setOf(1, 4), setOf(1, 2, 3), false},
+		{"larger", setOf(1, 2, 3, 4), setOf(1, 2, 3), false},
+		{"small in big", setOf(5, 500, 999), big, true},
+		{"small not in big", setOf(5, 1000), big, false},
+		{"big in big", big.Difference(setOf(7)), big, true},
+		{"big not in big", big.Difference(setOf(7)).Union(setOf(2000)), big, false},
+	}
+	for _, tt := range tests {
+		if got := tt.s.IsSubset(tt.o); got != tt.want {
+			t.Errorf("%s: IsSubset got %t, want %t", tt.name, got, tt.want)
+		}
+		if got := tt.o.IsSuperset(tt.s); got != tt.want {
+			t.Errorf("%s: IsSuperset got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// IsSubset should stop at the first missing key, not walk both sets to the end
+func TestSet_IsSubsetStopsEarly(t *testing.T) {
+	compares := 0
+	counting := func(a, b int) int {
+		compares++
+		return a - b
+	}
+	s := New[int](counting)
+	o := New[int](counting)
+	for i := 0; i < 1000; i++ {
+		s.Add(i)
+		o.Add(i + 1)
+	}
+	compares = 0
+	if s.IsSubset(o) {
+		t.Errorf("0 is missing from o, IsSubset should be false")
+	}
+	if compares > 10 {
+		t.Errorf("IsSubset made %d comparisons to find the first key missing", compares)
+	}
+}
@@ -0,0 +1,296 @@
+package splay
+
+type node[K any, V any] struct {
+	key     K
+	val     V
+	l, r, p *node[K, V]
+}
+
+// Splay is a self-adjusting binary search tree. every access rotates the touched
+// node to the root, so recently used keys stay near the top. no single operation is
+// guaranteed O(log n), but any sequence of m operations is O(m log n), and skewed
+// access patterns do much better than that
+type Splay[K any, V any] struct {
+	root    *node[K, V]
+	size    int
+	compare func(K, K) int
+}
+
+func newNode[K any, V any](k K, v V) *node[K, V] {
+	return &node[K, V]{
+		key: k,
+		val: v,
+	}
+}
+
+func New[K any, V any](compare func(K, K) int) *Splay[K, V] {
+	return &Splay[K, V]{root: nil, compare: compare}
+}
+
+func (t *Splay[K, V]) Insert(k K, v V) (oldValue V, replaced bool) {
+
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		y = x
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			prev := x.val
+			x.val = v
+			t.splay(x)
+			return prev, true
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	z := newNode(k, v)
+	z.p = y
+	if y == nil {
+		t.root = z
+	} else if t.compare(z.key, y.key) < 0 {
+		y.l = z
+	} else {
+		y.r = z
+	}
+	t.size++
+	t.splay(z)
+	return oldValue, false
+}
+
+// rotate moves x above its parent, keeping the in order sequence the same
+func (t *Splay[K, V]) rotate(x *node[K, V]) {
+	p := x.p
+	g := p.p
+	if x == p.l {
+		p.l = x.r
+		if x.r != nil {
+			x.r.p = p
+		}
+		x.r = p
+	} else {
+		p.r = x.l
+		if x.l != nil {
+			x.l.p = p
+		}
+		x.l = p
+	}
+	p.p = x
+	x.p = g
+	if g == nil {
+		t.root = x
+	} else if g.l == p {
+		g.l = x
+	} else {
+		g.r = x
+	}
+}
+
+// splay rotates x to the root. zig-zig rotates the parent first, which is what
+// roughly halves the depth of every node on the access path
+func (t *Splay[K, V]) splay(x *node[K, V]) {
+	for x.p != nil {
+		p := x.p
+		g := p.p
+		if g == nil {
+			// zig
+			t.rotate(x)
+		} else if (x == p.l) == (p == g.l) {
+			// zig-zig
+			t.rotate(p)
+			t.rotate(x)
+		} else {
+			// zig-zag
+			t.rotate(x)
+			t.rotate(x)
+		}
+	}
+}
+
+// Height calculates how many nodes from the top of the tree
+// to the bottom of the tree on the longest path, including the root
+// this means that the number of possible nodes at each height does not
+// obey the theoretical rule of n=2^h (i.e., height 1 (the root) has one node, not two)
+func (t *Splay[K, V]) Height() int {
+	return t.height(t.root)
+}
+
+func (t *Splay[K, V]) height(x *node[K, V]) int {
+	if x == nil {
+		return 0
+	} else {
+		l := t.height(x.l)
+		r := t.height(x.r)
+		if l < r {
+			return r + 1
+		} else {
+			return l + 1
+		}
+	}
+}
+
+func (t *Splay[K, V]) Remove(k K) (oldValue V, found bool) {
+	x := t.search(k)
+	if x == nil {
+		return oldValue, false
+	}
+	// x is now the root. the largest key on the left side is splayed to the top of
+	// the left side, it has no right child so the right side hangs there
+	l, r := x.l, x.r
+	if l == nil {
+		t.root = r
+		if r != nil {
+			r.p = nil
+		}
+	} else {
+		l.p = nil
+		m := t.maximum(l)
+		t.splay(m)
+		m.r = r
+		if r != nil {
+			r.p = m
+		}
+		t.root = m
+	}
+	t.size--
+	return x.val, true
+}
+
+func (t *Splay[K, V]) Search(k K) (val V, found bool) {
+	x := t.search(k)
+	if x == nil {
+		return val, false
+	} else {
+		return x.val, true
+	}
+}
+
+// search splays the node holding k, or the last node on the path if k is missing
+// so that unsuccessful lookups are paid for too
+func (t *Splay[K, V]) search(k K) *node[K, V] {
+	x := t.root
+	var y *node[K, V] = nil
+	for x != nil {
+		y = x
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			t.splay(x)
+			return x
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	if y != nil {
+		t.splay(y)
+	}
+	return nil
+}
+
+func (t *Splay[K, V]) Clear() {
+	t.root = nil
+	t.size = 0
+}
+
+func (t *Splay[K, V]) Size() int {
+	return t.size
+}
+
+func (t *Splay[K, V]) ContainsKey(k K) bool {
+	x := t.search(k)
+	if x != nil {
+		return true
+	} else {
+		return false
+	}
+}
+
+// ContainsValue does not splay, scanning every node would leave the tree as a chain
+func (t *Splay[K, V]) ContainsValue(v V, cmp func(V, V) int) bool {
+	if t.root == nil {
+		return false
+	}
+	for x := t.minimum(t.root); x != nil; x = t.successor(x) {
+		if cmp(x.val, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Splay[K, V]) minimum(x *node[K, V]) *node[K, V] {
+	for x.l != nil {
+		x = x.l
+	}
+	return x
+}
+
+func (t *Splay[K, V]) maximum(x *node[K, V]) *node[K, V] {
+	for x.r != nil {
+		x = x.r
+	}
+	return x
+}
+
+// Successor splays k, after which the successor is the minimum of the right subtree
+func (t *Splay[K, V]) Successor(k K) (val V, found bool) {
+	x := t.search(k)
+	if x == nil {
+		return val, false
+	}
+	x = t.successor(x)
+	if x != nil {
+		return x.val, true
+	} else {
+		return val, false
+	}
+}
+
+func (t *Splay[K, V]) successor(x *node[K, V]) *node[K, V] {
+	if x.r != nil {
+		return t.minimum(x.r)
+	} else {
+		y := x.p
+		for y != nil && x == y.r {
+			x = y
+			y = y.p
+		}
+		return y
+	}
+}
+
+func (t *Splay[K, V]) Predecessor(k K) (val V, found bool) {
+	x := t.search(k)
+	if x == nil {
+		return val, false
+	}
+	x = t.predecessor(x)
+	if x != nil {
+		return x.val, true
+	} else {
+		return val, false
+	}
+}
+
+func (t *Splay[K, V]) predecessor(x *node[K, V]) *node[K, V] {
+	if x.l != nil {
+		return t.maximum(x.l)
+	} else {
+		y := x.p
+		for y != nil && x == y.l {
+			x = y
+			y = y.p
+		}
+		return y
+	}
+}
+
+func (t *Splay[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
+	if x != nil {
+		t.traverse(x.l, action)
+		action(x)
+		t.traverse(x.r, action)
+	}
+}
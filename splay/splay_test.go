@@ -0,0 +1,312 @@
+package splay
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/a-tk/go-datastructures/rbtree"
+)
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+// zipfKeys draws lookups where a handful of keys get most of the traffic,
+// a larger s means a smaller hot set
+func zipfKeys(n int, s float64, max uint64) []int {
+	r := rand.New(rand.NewSource(123))
+	z := rand.NewZipf(r, s, 1, max)
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = int(z.Uint64())
+	}
+	return keys
+}
+
+const benchKeys = 100000
+
+var zipfExponents = []struct {
+	name string
+	s    float64
+}{{"s=1.1", 1.1}, {"s=1.5", 1.5}, {"s=2", 2}}
+
+// splay loses to rbtree at s=1.1, is close at s=1.5 and wins by ~1.5x at s=2,
+// the rotations only pay for themselves once the hot set is small
+func BenchmarkSplay_ZipfSearch(b *testing.B) {
+	for _, z := range zipfExponents {
+		b.Run(z.name, func(b *testing.B) {
+			tree := New[int, int](intcmp)
+			// shuffle the inserts so the hot keys are not already near the root
+			for _, k := range rand.New(rand.NewSource(1)).Perm(benchKeys) {
+				tree.Insert(k, k)
+			}
+			keys := zipfKeys(1<<16, z.s, benchKeys-1)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tree.Search(keys[i&(len(keys)-1)])
+			}
+		})
+	}
+}
+
+func BenchmarkRBTree_ZipfSearch(b *testing.B) {
+	for _, z := range zipfExponents {
+		b.Run(z.name, func(b *testing.B) {
+			tree := rbtree.New[int, int](intcmp)
+			for _, k := range rand.New(rand.NewSource(1)).Perm(benchKeys) {
+				tree.Insert(k, k)
+			}
+			keys := zipfKeys(1<<16, z.s, benchKeys-1)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tree.Search(keys[i&(len(keys)-1)])
+			}
+		})
+	}
+}
+
+func BenchmarkSplay_UniformSearch(b *testing.B) {
+	tree := New[int, int](intcmp)
+	for _, k := range rand.New(rand.NewSource(1)).Perm(benchKeys) {
+		tree.Insert(k, k)
+	}
+	r := rand.New(rand.NewSource(123))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Search(r.Intn(benchKeys))
+	}
+}
+
+func BenchmarkRBTree_UniformSearch(b *testing.B) {
+	tree := rbtree.New[int, int](intcmp)
+	for _, k := range rand.New(rand.NewSource(1)).Perm(benchKeys) {
+		tree.Insert(k, k)
+	}
+	r := rand.New(rand.NewSource(123))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Search(r.Intn(benchKeys))
+	}
+}
+
+func TestSplay_Insert(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	x := valueSlice(b)
+
+	if !slices.IsSortedFunc(x, strings.Compare) {
+		t.Errorf("inserts were not sorted!")
+	}
+	if !slices.Equal(x, []string{"1", "3", "4", "5"}) {
+		t.Errorf("missing inserts, got %v", x)
+	}
+	if b.root.key != 1 {
+		t.Errorf("last insert should be the root, got %d", b.root.key)
+	}
+	checkLinks(t, b)
+}
+
+func TestSplay_InsertDup(t *testing.T) {
+	b := New[int, string](intcmp)
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	got, _ := b.Insert(4, "166")
+	if got != "4" {
+		t.Errorf("inserting a duplicate, should see previous value 4, got %s", got)
+	}
+
+	got, _ = b.Search(4)
+
+	if got != "166" {
+		t.Errorf("duplicate Key K was replaces with 166, got %s", got)
+	}
+	if b.Size() != 4 {
+		t.Errorf("size %d, want 4", b.Size())
+	}
+}
+
+func TestSplay_SearchSplays(t *testing.T) {
+	b := New[int, int](intcmp)
+	for i := 0; i < 100; i++ {
+		b.Insert(i, i)
+	}
+
+	b.Search(42)
+	if b.root.key != 42 {
+		t.Errorf("search should move 42 to the root, got %d", b.root.key)
+	}
+
+	// a missing key splays the last node on its path
+	b.Search(1000)
+	if b.root.key != 99 {
+		t.Errorf("search for a missing key should splay 99, got %d", b.root.key)
+	}
+	checkLinks(t, b)
+}
+
+func TestSplay_Predecessor(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	_, found := b.Predecessor(2)
+	if found {
+		t.Errorf("there should be no predecessor of min")
+	}
+	got, _ := b.Predecessor(15)
+	if got != 13 {
+		t.Errorf("wrong predecessor of 15, got %d", got)
+	}
+	got, _ = b.Predecessor(17)
+	if got != 15 {
+		t.Errorf("wrong predecessor of 17, got %d", got)
+	}
+	_, found = b.Predecessor(10)
+	if found {
+		t.Errorf("there should be no predecessor of a missing key")
+	}
+}
+
+func TestSplay_Successor(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	got, found := b.Successor(20)
+	if found {
+		t.Errorf("there should be no successor of max, got %d", got)
+	}
+	got, _ = b.Successor(15)
+	if got != 17 {
+		t.Errorf("wrong successor of 15, got %d", got)
+	}
+	got, _ = b.Successor(13)
+	if got != 15 {
+		t.Errorf("wrong successor of 13, got %d", got)
+	}
+}
+
+func TestSplay_Remove(t *testing.T) {
+	b := New[int, int](intcmp)
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	for _, k := range []int{13, 2, 3, 17, 15, 20} {
+		got, found := b.Remove(k)
+		if !found || got != k {
+			t.Errorf("%d not returned by remove", k)
+		}
+
+		x := valueSlice(b)
+		if !slices.IsSortedFunc(x, intcmp) {
+			t.Errorf("tree structure incorrect")
+		}
+		if slices.Contains(x, k) {
+			t.Errorf("%d not removed", k)
+		}
+		checkLinks(t, b)
+	}
+
+	if b.Size() != 5 {
+		t.Errorf("Size incorrect! expected 5 but was %d", b.Size())
+	}
+
+	_, found := b.Remove(2500)
+	if found {
+		t.Errorf("error, should return nil for keys not in the tree")
+	}
+}
+
+func TestSplay_Random(t *testing.T) {
+	b := New[int, int](intcmp)
+	r := rand.New(rand.NewSource(321))
+	m := map[int]int{}
+
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(300)
+		switch r.Intn(3) {
+		case 0:
+			b.Insert(k, i)
+			m[k] = i
+		case 1:
+			b.Remove(k)
+			delete(m, k)
+		default:
+			v, found := b.Search(k)
+			want, ok := m[k]
+			if found != ok || v != want {
+				t.Fatalf("Search(%d) got %d %t, want %d %t", k, v, found, want, ok)
+			}
+		}
+	}
+
+	if b.Size() != len(m) {
+		t.Errorf("size %d, want %d", b.Size(), len(m))
+	}
+	checkLinks(t, b)
+}
+
+func TestSplay_Clear(t *testing.T) {
+	b := New[int, string](intcmp)
+	b.Insert(1, "1")
+	b.Insert(2, "2")
+
+	if !b.ContainsValue("2", strings.Compare) || b.ContainsValue("3", strings.Compare) {
+		t.Errorf("ContainsValue incorrect")
+	}
+
+	b.Clear()
+	if b.Size() != 0 || b.root != nil || b.ContainsKey(1) {
+		t.Errorf("after clear there should be no nodes")
+	}
+}
+
+// checkLinks checks ordering and parent pointers
+func checkLinks[K any, V any](t *testing.T, b *Splay[K, V]) {
+	t.Helper()
+	if b.root != nil && b.root.p != nil {
+		t.Errorf("root has a parent")
+	}
+	var check func(x *node[K, V])
+	check = func(x *node[K, V]) {
+		if x == nil {
+			return
+		}
+		if x.l != nil && (x.l.p != x || b.compare(x.l.key, x.key) >= 0) ||
+			x.r != nil && (x.r.p != x || b.compare(x.r.key, x.key) <= 0) {
+			t.Errorf("bad link or order at %v", x.key)
+		}
+		check(x.l)
+		check(x.r)
+	}
+	check(b.root)
+}
+
+func valueSlice[K any, V any](b *Splay[K, V]) []V {
+	var x []V
+	b.traverse(b.root, func(n *node[K, V]) {
+		x = append(x, n.val)
+	})
+	return x
+}
@@ -0,0 +1,223 @@
+package treap
+
+import "math/rand/v2"
+
+type node[K any, V any] struct {
+	key      K
+	val      V
+	priority uint64
+	size     int // nodes in this subtree, kept so Split can size both halves
+	l, r     *node[K, V]
+}
+
+// Treap is a binary search tree on the keys and a max heap on random priorities.
+// the priorities make the shape the same as inserting the keys in a random order,
+// so every operation is expected O(log n) no matter the insertion order
+type Treap[K any, V any] struct {
+	root    *node[K, V]
+	compare func(K, K) int
+	rand    *rand.Rand
+}
+
+func New[K any, V any](compare func(K, K) int) *Treap[K, V] {
+	return NewWithSource[K, V](compare, rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// NewWithSource draws priorities from src, a fixed seed gives the same tree shape every run
+func NewWithSource[K any, V any](compare func(K, K) int, src rand.Source) *Treap[K, V] {
+	return &Treap[K, V]{
+		root:    nil,
+		compare: compare,
+		rand:    rand.New(src),
+	}
+}
+
+func (t *Treap[K, V]) Insert(k K, v V) (old V, replaced bool) {
+	x := t.search(k)
+	if x != nil {
+		prev := x.val
+		x.val = v
+		return prev, true
+	}
+	t.root = t.insert(t.root, &node[K, V]{key: k, val: v, priority: t.rand.Uint64(), size: 1})
+	return old, false
+}
+
+// insert walks down until z has a higher priority than the current node,
+// then z takes its place with the subtree split around z's key as children
+func (t *Treap[K, V]) insert(x *node[K, V], z *node[K, V]) *node[K, V] {
+	if x == nil {
+		return z
+	}
+	if z.priority > x.priority {
+		z.l, z.r = t.split(x, z.key)
+		t.update(z)
+		return z
+	}
+	if t.compare(z.key, x.key) < 0 {
+		x.l = t.insert(x.l, z)
+	} else {
+		x.r = t.insert(x.r, z)
+	}
+	t.update(x)
+	return x
+}
+
+func (t *Treap[K, V]) Remove(k K) (old V, found bool) {
+	var removed *node[K, V]
+	t.root = t.remove(t.root, k, &removed)
+	if removed == nil {
+		return old, false
+	}
+	return removed.val, true
+}
+
+// remove replaces the node holding k with the merge of its children
+func (t *Treap[K, V]) remove(x *node[K, V], k K, removed **node[K, V]) *node[K, V] {
+	if x == nil {
+		return nil
+	}
+	cmp := t.compare(k, x.key)
+	if cmp == 0 {
+		*removed = x
+		return t.merge(x.l, x.r)
+	} else if cmp < 0 {
+		x.l = t.remove(x.l, k, removed)
+	} else {
+		x.r = t.remove(x.r, k, removed)
+	}
+	t.update(x)
+	return x
+}
+
+func (t *Treap[K, V]) Search(k K) (val V, found bool) {
+	x := t.search(k)
+	if x == nil {
+		return val, false
+	} else {
+		return x.val, true
+	}
+}
+
+func (t *Treap[K, V]) search(k K) *node[K, V] {
+	x := t.root
+	for x != nil {
+		cmp := t.compare(k, x.key)
+		if cmp == 0 {
+			return x
+		} else if cmp < 0 {
+			x = x.l
+		} else {
+			x = x.r
+		}
+	}
+	return nil
+}
+
+func (t *Treap[K, V]) ContainsKey(k K) bool {
+	return t.search(k) != nil
+}
+
+func (t *Treap[K, V]) Size() int {
+	return t.size(t.root)
+}
+
+func (t *Treap[K, V]) size(x *node[K, V]) int {
+	if x == nil {
+		return 0
+	}
+	return x.size
+}
+
+func (t *Treap[K, V]) update(x *node[K, V]) {
+	x.size = 1 + t.size(x.l) + t.size(x.r)
+}
+
+// Height calculates how many nodes from the top of the tree
+// to the bottom of the tree on the longest path, including the root
+func (t *Treap[K, V]) Height() int {
+	return t.height(t.root)
+}
+
+func (t *Treap[K, V]) height(x *node[K, V]) int {
+	if x == nil {
+		return 0
+	}
+	return max(t.height(x.l), t.height(x.r)) + 1
+}
+
+// Split divides the treap into keys less than k and keys greater than or equal to k,
+// expected O(log n). t is left empty and both halves keep drawing priorities from t's source
+func (t *Treap[K, V]) Split(k K) (left, right *Treap[K, V]) {
+	l, r := t.split(t.root, k)
+	t.root = nil
+	return &Treap[K, V]{root: l, compare: t.compare, rand: t.rand},
+		&Treap[K, V]{root: r, compare: t.compare, rand: t.rand}
+}
+
+// Merge moves every key of other into t, expected O(log n).
+// every key in t must be less than every key in other, and other is left empty
+func (t *Treap[K, V]) Merge(other *Treap[K, V]) {
+	if t.root != nil && other.root != nil && t.compare(t.maximum(t.root).key, t.minimum(other.root).key) >= 0 {
+		panic("treap: Merge keys are out of order")
+	}
+	t.root = t.merge(t.root, other.root)
+	other.root = nil
+}
+
+// split returns the subtrees of keys less than k and keys greater than or equal to k
+func (t *Treap[K, V]) split(x *node[K, V], k K) (l, r *node[K, V]) {
+	if x == nil {
+		return nil, nil
+	}
+	if t.compare(x.key, k) < 0 {
+		x.r, r = t.split(x.r, k)
+		t.update(x)
+		return x, r
+	} else {
+		l, x.l = t.split(x.l, k)
+		t.update(x)
+		return l, x
+	}
+}
+
+// merge joins two subtrees where every key in a is less than every key in b.
+// the root with the higher priority stays on top
+func (t *Treap[K, V]) merge(a, b *node[K, V]) *node[K, V] {
+	if a == nil {
+		return b
+	} else if b == nil {
+		return a
+	}
+	if a.priority > b.priority {
+		a.r = t.merge(a.r, b)
+		t.update(a)
+		return a
+	} else {
+		b.l = t.merge(a, b.l)
+		t.update(b)
+		return b
+	}
+}
+
+func (t *Treap[K, V]) minimum(x *node[K, V]) *node[K, V] {
+	for x.l != nil {
+		x = x.l
+	}
+	return x
+}
+
+func (t *Treap[K, V]) maximum(x *node[K, V]) *node[K, V] {
+	for x.r != nil {
+		x = x.r
+	}
+	return x
+}
+
+func (t *Treap[K, V]) traverse(x *node[K, V], action func(*node[K, V])) {
+	if x != nil {
+		t.traverse(x.l, action)
+		action(x)
+		t.traverse(x.r, action)
+	}
+}
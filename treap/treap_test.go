@@ -0,0 +1,215 @@
+package treap
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func BenchmarkTreap_Insert(b *testing.B) {
+
+	tree := NewWithSource[int, int](intcmp, rand.NewPCG(1, 2))
+	r := rand.New(rand.NewPCG(123, 0))
+	duplicates := 0
+
+	for i := 0; i < b.N; i++ {
+		obj := r.Int()
+		_, found := tree.Insert(obj, obj)
+		if found {
+			duplicates++
+		}
+	}
+}
+
+func BenchmarkTreap_InOrderInsert(b *testing.B) {
+
+	tree := NewWithSource[int, int](intcmp, rand.NewPCG(1, 2))
+	duplicates := 0
+
+	for i := 0; i < b.N; i++ {
+		_, found := tree.Insert(i, i)
+		if found {
+			duplicates++
+		}
+	}
+}
+
+func intcmp(a, b int) int {
+	return a - b
+}
+
+func newTreap() *Treap[int, int] {
+	return NewWithSource[int, int](intcmp, rand.NewPCG(1, 2))
+}
+
+func TestTreap_Insert(t *testing.T) {
+	b := NewWithSource[int, string](intcmp, rand.NewPCG(1, 2))
+
+	b.Insert(5, "5")
+	b.Insert(3, "3")
+	b.Insert(4, "4")
+	b.Insert(1, "1")
+
+	x := valueSlice(b)
+
+	if !slices.IsSortedFunc(x, strings.Compare) {
+		t.Errorf("inserts were not sorted!")
+	}
+	if !slices.Equal(x, []string{"1", "3", "4", "5"}) {
+		t.Errorf("missing inserts, got %v", x)
+	}
+
+	got, found := b.Insert(4, "166")
+	if !found || got != "4" {
+		t.Errorf("inserting a duplicate, should see previous value 4, got %s", got)
+	}
+	got, _ = b.Search(4)
+	if got != "166" {
+		t.Errorf("duplicate Key K was replaces with 166, got %s", got)
+	}
+	if b.Size() != 4 {
+		t.Errorf("size %d, want 4", b.Size())
+	}
+	checkTreap(t, b)
+}
+
+func TestTreap_Remove(t *testing.T) {
+	b := newTreap()
+
+	for _, k := range []int{15, 6, 3, 2, 4, 7, 13, 9, 18, 17, 20} {
+		b.Insert(k, k)
+	}
+
+	for _, k := range []int{13, 2, 3, 17, 15} {
+		got, found := b.Remove(k)
+		if !found || got != k {
+			t.Errorf("%d not returned by remove", k)
+		}
+		if b.ContainsKey(k) {
+			t.Errorf("%d not removed", k)
+		}
+		checkTreap(t, b)
+	}
+
+	if b.Size() != 6 {
+		t.Errorf("size %d, want 6", b.Size())
+	}
+
+	_, found := b.Remove(2500)
+	if found {
+		t.Errorf("error, should return nil for keys not in the tree")
+	}
+}
+
+func TestTreap_Deterministic(t *testing.T) {
+	a := newTreap()
+	b := newTreap()
+	for i := 0; i < 1000; i++ {
+		a.Insert(i, i)
+		b.Insert(i, i)
+	}
+
+	if a.Height() != b.Height() {
+		t.Errorf("same source should give the same shape, heights %d and %d", a.Height(), b.Height())
+	}
+
+	// sorted input would be a chain in a plain bst
+	if a.Height() > 40 {
+		t.Errorf("height %d is too tall for 1000 keys", a.Height())
+	}
+}
+
+func TestTreap_Split(t *testing.T) {
+	for _, k := range []int{-1, 0, 25, 50, 51, 99, 150} {
+		b := newTreap()
+		for i := 0; i < 100; i += 2 {
+			b.Insert(i, i)
+		}
+
+		l, r := b.Split(k)
+		checkTreap(t, l)
+		checkTreap(t, r)
+
+		for _, key := range keySlice(l) {
+			if key >= k {
+				t.Errorf("split at %d, left has %d", k, key)
+			}
+		}
+		for _, key := range keySlice(r) {
+			if key < k {
+				t.Errorf("split at %d, right has %d", k, key)
+			}
+		}
+		if l.Size()+r.Size() != 50 || l.Size() != len(keySlice(l)) {
+			t.Errorf("split at %d has wrong sizes, %d + %d", k, l.Size(), r.Size())
+		}
+		if b.Size() != 0 {
+			t.Errorf("split should leave the treap empty")
+		}
+	}
+}
+
+func TestTreap_Merge(t *testing.T) {
+	b := newTreap()
+	for i := 0; i < 100; i++ {
+		b.Insert(i, i)
+	}
+
+	l, r := b.Split(40)
+	l.Merge(r)
+	checkTreap(t, l)
+
+	if l.Size() != 100 || r.Size() != 0 {
+		t.Errorf("merge sizes %d and %d, want 100 and 0", l.Size(), r.Size())
+	}
+	if !slices.IsSorted(keySlice(l)) {
+		t.Errorf("merged keys are out of order")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("merging out of order keys should panic")
+		}
+	}()
+	other := newTreap()
+	other.Insert(5, 5)
+	l.Merge(other)
+}
+
+// checkTreap checks the key ordering, the heap ordering of the priorities and the sizes
+func checkTreap[K any, V any](t *testing.T, b *Treap[K, V]) {
+	t.Helper()
+	var check func(x *node[K, V]) int
+	check = func(x *node[K, V]) int {
+		if x == nil {
+			return 0
+		}
+		if x.l != nil && (b.compare(x.l.key, x.key) >= 0 || x.l.priority > x.priority) ||
+			x.r != nil && (b.compare(x.r.key, x.key) <= 0 || x.r.priority > x.priority) {
+			t.Errorf("heap or key order broken at %v", x.key)
+		}
+		n := 1 + check(x.l) + check(x.r)
+		if x.size != n {
+			t.Errorf("node %v has size %d, want %d", x.key, x.size, n)
+		}
+		return n
+	}
+	check(b.root)
+}
+
+func valueSlice[K any, V any](b *Treap[K, V]) []V {
+	var x []V
+	b.traverse(b.root, func(n *node[K, V]) {
+		x = append(x, n.val)
+	})
+	return x
+}
+
+func keySlice[K any, V any](b *Treap[K, V]) []K {
+	var x []K
+	b.traverse(b.root, func(n *node[K, V]) {
+		x = append(x, n.key)
+	})
+	return x
+}